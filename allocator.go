@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bitrise-steplib/steps-change-android-versioncode-and-versionname/allocator"
+)
+
+// leaseAllocatorVersionCode requests the versionCode of the build from the allocation service.
+// The leased versionCode is at least the current versionCode + 1, the current versionCode includes the offset, which is subtracted.
+// With the offset, the leased versionCode must not exceed the Google Play ceiling.
+func leaseAllocatorVersionCode(cfg config, currentVersionCode string) (int, error) {
+	if cfg.AllocatorURL == "" || cfg.AllocatorAppID == "" || cfg.AllocatorBuildID == "" {
+		return 0, fmt.Errorf("allocator_url, allocator_app_id and allocator_build_id are required for the allocator versionCode source")
+	}
+
+	minVersionCode := 0
+	if current, err := strconv.Atoi(currentVersionCode); err == nil {
+		minVersionCode = current - cfg.VersionCodeOffset + 1
+	}
+
+	client := allocator.NewClient(cfg.AllocatorURL, &http.Client{Timeout: 30 * time.Second})
+	code, err := client.Lease(cfg.AllocatorAppID, cfg.AllocatorBuildID, minVersionCode)
+	if err != nil {
+		return 0, err
+	}
+	if code <= 0 || code+cfg.VersionCodeOffset > versionCodeMax {
+		return 0, fmt.Errorf("leased versionCode (%d) with offset (%d) is out of the Google Play range ]0..%d]", code, cfg.VersionCodeOffset, versionCodeMax)
+	}
+	return code, nil
+}
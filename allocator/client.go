@@ -0,0 +1,52 @@
+package allocator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client requests versionCodes from the allocation service.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient constructs a new Client.
+func NewClient(baseURL string, httpClient *http.Client) Client {
+	return Client{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: httpClient}
+}
+
+// Lease requests the versionCode of the build of the app. Retrying with the same build ID returns the same versionCode.
+func (c Client) Lease(appID, buildID string, minVersionCode int) (int, error) {
+	body, err := json.Marshal(LeaseRequest{BuildID: buildID, MinVersionCode: minVersionCode})
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.httpClient.Post(c.baseURL+leasesPathPrefix+url.PathEscape(appID)+"/leases", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("lease request failed: %s: %s", resp.Status, strings.TrimSpace(string(content)))
+	}
+
+	var lease LeaseResponse
+	if err := json.Unmarshal(content, &lease); err != nil {
+		return 0, fmt.Errorf("invalid lease response: %s", err)
+	}
+	return lease.VersionCode, nil
+}
@@ -0,0 +1,64 @@
+package allocator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const leasesPathPrefix = "/v1/apps/"
+
+// LeaseRequest is the body of a lease request.
+type LeaseRequest struct {
+	BuildID        string `json:"build_id"`
+	MinVersionCode int    `json:"min_version_code"`
+}
+
+// LeaseResponse is the body of a successful lease response.
+type LeaseResponse struct {
+	AppID       string `json:"app_id"`
+	BuildID     string `json:"build_id"`
+	VersionCode int    `json:"version_code"`
+}
+
+// Leaser leases versionCodes.
+type Leaser interface {
+	Lease(appID, buildID string, minVersionCode int) (int, error)
+}
+
+// NewHandler returns the HTTP handler of the allocation service:
+// POST /v1/apps/{appID}/leases with a LeaseRequest body responds with a LeaseResponse.
+func NewHandler(leaser Leaser) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		appID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, leasesPathPrefix), "/leases")
+		if !strings.HasPrefix(r.URL.Path, leasesPathPrefix) || !strings.HasSuffix(r.URL.Path, "/leases") || appID == "" || strings.Contains(appID, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req LeaseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %s", err), http.StatusBadRequest)
+			return
+		}
+		if req.BuildID == "" {
+			http.Error(w, "build_id is required", http.StatusBadRequest)
+			return
+		}
+
+		code, err := leaser.Lease(appID, req.BuildID, req.MinVersionCode)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LeaseResponse{AppID: appID, BuildID: req.BuildID, VersionCode: code})
+	})
+}
@@ -0,0 +1,61 @@
+package allocator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_Lease(t *testing.T) {
+	server := httptest.NewServer(NewHandler(NewFileStore(filepath.Join(t.TempDir(), "store.json"), time.Second)))
+	defer server.Close()
+
+	c := NewClient(server.URL, server.Client())
+	first, err := c.Lease("com.example", "build-1", 41)
+	if err != nil {
+		t.Fatalf("Client.Lease() error = %v", err)
+	}
+	retry, err := c.Lease("com.example", "build-1", 41)
+	if err != nil {
+		t.Fatalf("Client.Lease() error = %v", err)
+	}
+	second, err := c.Lease("com.example", "build-2", 0)
+	if err != nil {
+		t.Fatalf("Client.Lease() error = %v", err)
+	}
+
+	if first != 41 || retry != 41 || second != 42 {
+		t.Errorf("Client.Lease() = %d, %d, %d, want 41, 41, 42", first, retry, second)
+	}
+
+	if _, err := c.Lease("com.example", "", 0); err == nil {
+		t.Errorf("Client.Lease() without build ID expected error")
+	}
+}
+
+func TestNewHandler_routes(t *testing.T) {
+	handler := NewHandler(NewFileStore(filepath.Join(t.TempDir(), "store.json"), time.Second))
+
+	tests := []struct {
+		method   string
+		path     string
+		wantCode int
+	}{
+		{method: http.MethodPost, path: "/v1/apps/com.example/leases", wantCode: http.StatusOK},
+		{method: http.MethodGet, path: "/v1/apps/com.example/leases", wantCode: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, path: "/v1/apps//leases", wantCode: http.StatusNotFound},
+		{method: http.MethodPost, path: "/v1/apps/com.example", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"build_id": "build-1"}`)))
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Package allocator hands out strictly increasing versionCodes per app, so that concurrent builds of the same app
+// never get the same versionCode. Leases are idempotent by build ID, retries of a build get the same versionCode.
+package allocator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// ErrLockTimeout is returned when the store lock could not be acquired in time.
+var ErrLockTimeout = errors.New("timed out waiting for the store lock")
+
+type appState struct {
+	Last   int            `json:"last"`
+	Leases map[string]int `json:"leases"`
+}
+
+// FileStore persists the allocated versionCodes in a JSON file.
+// An advisory lock on a lock file next to it serializes access across processes,
+// the lock is released by the OS if the process holding it dies.
+type FileStore struct {
+	pth         string
+	lockTimeout time.Duration
+	mu          *sync.Mutex
+}
+
+// NewFileStore constructs a new FileStore.
+func NewFileStore(pth string, lockTimeout time.Duration) FileStore {
+	return FileStore{pth: pth, lockTimeout: lockTimeout, mu: &sync.Mutex{}}
+}
+
+// Lease returns the versionCode leased to the build of the app.
+// A new build gets the greater of the app's last versionCode + 1 and minVersionCode.
+func (s FileStore) Lease(appID, buildID string, minVersionCode int) (int, error) {
+	if appID == "" || buildID == "" {
+		return 0, errors.New("app ID and build ID are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	state, err := s.read()
+	if err != nil {
+		return 0, err
+	}
+
+	app := state[appID]
+	if app.Leases == nil {
+		app.Leases = map[string]int{}
+	}
+	if code, ok := app.Leases[buildID]; ok {
+		return code, nil
+	}
+
+	code := app.Last + 1
+	if code < minVersionCode {
+		code = minVersionCode
+	}
+	app.Last = code
+	app.Leases[buildID] = code
+	state[appID] = app
+
+	if err := s.write(state); err != nil {
+		return 0, err
+	}
+	return code, nil
+}
+
+// lock acquires an exclusive flock on the lock file. The lock file is never removed,
+// so every process locks the same inode.
+func (s FileStore) lock() (func(), error) {
+	lockPth := s.pth + ".lock"
+	f, err := os.OpenFile(lockPth, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(s.lockTimeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return func() {
+				_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+				_ = f.Close()
+			}, nil
+		}
+		if err != syscall.EWOULDBLOCK && err != syscall.EINTR {
+			_ = f.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			_ = f.Close()
+			return nil, fmt.Errorf("%s: %s", ErrLockTimeout, lockPth)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (s FileStore) read() (map[string]appState, error) {
+	state := map[string]appState{}
+	if exists, err := pathutil.IsPathExists(s.pth); err != nil {
+		return nil, err
+	} else if !exists {
+		return state, nil
+	}
+	content, err := fileutil.ReadBytesFromFile(s.pth)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse store (%s): %s", s.pth, err)
+	}
+	return state, nil
+}
+
+// write replaces the store file atomically, so that a crash never leaves a partially written store behind.
+func (s FileStore) write(state map[string]appState) error {
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.pth), filepath.Base(s.pth)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.pth)
+}
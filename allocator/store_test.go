@@ -0,0 +1,90 @@
+package allocator
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileStore_Lease(t *testing.T) {
+	s := NewFileStore(filepath.Join(t.TempDir(), "store.json"), time.Second)
+
+	steps := []struct {
+		appID          string
+		buildID        string
+		minVersionCode int
+		want           int
+	}{
+		{appID: "com.example", buildID: "build-1", want: 1},
+		{appID: "com.example", buildID: "build-2", want: 2},
+		{appID: "com.example", buildID: "build-1", want: 1},
+		{appID: "com.example", buildID: "build-3", minVersionCode: 100, want: 100},
+		{appID: "com.example", buildID: "build-4", minVersionCode: 50, want: 101},
+		{appID: "com.example.other", buildID: "build-1", want: 1},
+	}
+	for _, step := range steps {
+		got, err := s.Lease(step.appID, step.buildID, step.minVersionCode)
+		if err != nil {
+			t.Fatalf("FileStore.Lease(%s, %s) error = %v", step.appID, step.buildID, err)
+		}
+		if got != step.want {
+			t.Errorf("FileStore.Lease(%s, %s) = %v, want %v", step.appID, step.buildID, got, step.want)
+		}
+	}
+}
+
+func TestFileStore_Lease_concurrent(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "store.json")
+
+	const builds = 20
+	codes := make([]int, builds)
+	var wg sync.WaitGroup
+	for i := 0; i < builds; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// separate stores share only the file, like separate processes
+			code, err := NewFileStore(pth, 5*time.Second).Lease("com.example", string(rune('a'+i)), 0)
+			if err != nil {
+				t.Errorf("FileStore.Lease() error = %v", err)
+			}
+			codes[i] = code
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[int]bool{}
+	for _, code := range codes {
+		if seen[code] || code < 1 || code > builds {
+			t.Fatalf("FileStore.Lease() codes = %v, want unique codes 1..%d", codes, builds)
+		}
+		seen[code] = true
+	}
+}
+
+func TestFileStore_Lease_leftoverLockFile(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "store.json")
+	// a crashed process leaves the lock file behind, but not the lock
+	if err := os.WriteFile(pth+".lock", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFileStore(pth, 100*time.Millisecond).Lease("com.example", "build-1", 0); err != nil {
+		t.Errorf("FileStore.Lease() error = %v", err)
+	}
+}
+
+func TestFileStore_Lease_lockTimeout(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "store.json")
+	unlock, err := NewFileStore(pth, time.Second).lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	if _, err := NewFileStore(pth, 50*time.Millisecond).Lease("com.example", "build-1", 0); err == nil {
+		t.Errorf("FileStore.Lease() error = nil, want %s", ErrLockTimeout)
+	}
+}
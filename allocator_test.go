@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-steplib/steps-change-android-versioncode-and-versionname/allocator"
+)
+
+func Test_leaseAllocatorVersionCode(t *testing.T) {
+	tests := []struct {
+		name               string
+		currentVersionCode string
+		versionCodeOffset  int
+		want               int
+		wantErr            bool
+	}{
+		{name: "Next to the current versionCode", currentVersionCode: "10", want: 11},
+		{name: "Offset is subtracted from the current versionCode", currentVersionCode: "5010", versionCodeOffset: 5000, want: 11},
+		{name: "Non numeric current versionCode", currentVersionCode: "rootProject.ext.versionCode", want: 1},
+		{name: "Exceeds the Google Play limit with the offset", currentVersionCode: "2100000000", versionCodeOffset: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := allocator.NewFileStore(filepath.Join(t.TempDir(), "store.json"), time.Second)
+			server := httptest.NewServer(allocator.NewHandler(store))
+			defer server.Close()
+
+			cfg := config{
+				AllocatorURL:      server.URL,
+				AllocatorAppID:    "com.example",
+				AllocatorBuildID:  "build-1",
+				VersionCodeOffset: tt.versionCodeOffset,
+			}
+			got, err := leaseAllocatorVersionCode(cfg, tt.currentVersionCode)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("leaseAllocatorVersionCode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("leaseAllocatorVersionCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
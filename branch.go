@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// BranchVersionName builds a versionName from a release branch name.
+type BranchVersionName struct {
+	pattern  *regexp.Regexp
+	template string
+}
+
+// NewBranchVersionName constructs a new BranchVersionName.
+// The template refers to the named capture groups of the pattern as {name}.
+func NewBranchVersionName(pattern *regexp.Regexp, template string) BranchVersionName {
+	return BranchVersionName{pattern: pattern, template: template}
+}
+
+// Generate returns the versionName for the given branch.
+// Named groups which did not participate in the match are substituted with 0,
+// additional placeholders (like {build}) are taken from the extra map.
+func (b BranchVersionName) Generate(branch string, extra map[string]string) (string, error) {
+	match := b.pattern.FindStringSubmatch(branch)
+	if match == nil {
+		return "", fmt.Errorf("branch (%s) does not match pattern (%s)", branch, b.pattern)
+	}
+
+	values := map[string]string{}
+	for k, v := range extra {
+		values[k] = v
+	}
+	for i, name := range b.pattern.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		values[name] = match[i]
+		if values[name] == "" {
+			values[name] = "0"
+		}
+	}
+
+	return placeholders.Expand(b.template, placeholderValues(values))
+}
+
+// generateBranchVersionName builds the versionName from the configured branch, or the current one.
+func generateBranchVersionName(cfg config) (string, error) {
+	pattern, err := regexp.Compile(cfg.BranchPattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid branch pattern (%s): %s", cfg.BranchPattern, err)
+	}
+
+	branch := cfg.Branch
+	if branch == "" {
+		if branch, err = NewGitRepository(cfg.GitRepositoryPth).CurrentBranch(); err != nil {
+			return "", err
+		}
+	}
+	log.Printf("branch: %s", branch)
+
+	return NewBranchVersionName(pattern, cfg.BranchVersionNameTemplate).Generate(branch, map[string]string{"build": cfg.BuildNumber})
+}
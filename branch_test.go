@@ -0,0 +1,36 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestBranchVersionName_Generate(t *testing.T) {
+	pattern := regexp.MustCompile(`^(?:release|hotfix)/(?P<major>\d+)\.(?P<minor>\d+)(?:\.(?P<patch>\d+))?$`)
+
+	tests := []struct {
+		name     string
+		template string
+		branch   string
+		want     string
+		wantErr  bool
+	}{
+		{name: "Release branch without patch", template: "{major}.{minor}.{patch}", branch: "release/1.5", want: "1.5.0"},
+		{name: "Hotfix branch", template: "{major}.{minor}.{patch}", branch: "hotfix/1.4.3", want: "1.4.3"},
+		{name: "Extra placeholder", template: "{major}.{minor}.{patch}-rc.{build}", branch: "release/1.5", want: "1.5.0-rc.42"},
+		{name: "Branch does not match", template: "{major}.{minor}.{patch}", branch: "feature/login", wantErr: true},
+		{name: "Unknown placeholder", template: "{major}.{minor}.{micro}", branch: "release/1.5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBranchVersionName(pattern, tt.template).Generate(tt.branch, map[string]string{"build": "42"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BranchVersionName.Generate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BranchVersionName.Generate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+var gradleFieldRegexp = regexp.MustCompile(`\b(buildConfigField|resValue)\b[ \t]*\(?[ \t]*`)
+
+// GradleField is a buildConfigField or resValue declaration with string literal arguments.
+type GradleField struct {
+	// Method is buildConfigField or resValue.
+	Method string
+	Type   string
+	Name   string
+	// Value is the unescaped value argument, for buildConfigField it is a Java expression, like "1.2.0" with the quotes.
+	Value string
+	Line  int
+	// Start and End are the offsets of the value argument, including its quotes, in the build.gradle content.
+	Start int
+	End   int
+}
+
+// FindGradleFields returns the buildConfigField and resValue declarations of the build.gradle content.
+// Declarations with non literal arguments and commented out lines are skipped.
+func FindGradleFields(content string) []GradleField {
+	var fields []GradleField
+	for _, m := range gradleFieldRegexp.FindAllStringSubmatchIndex(content, -1) {
+		lineStart := strings.LastIndex(content[:m[0]], "\n") + 1
+		if line := strings.TrimSpace(content[lineStart:m[0]]); strings.HasPrefix(line, "//") || strings.HasPrefix(line, "*") {
+			continue
+		}
+
+		field := GradleField{Method: content[m[2]:m[3]], Line: strings.Count(content[:m[0]], "\n") + 1}
+		var args []string
+		pos := m[1]
+		for i := 0; i < 3; i++ {
+			if i > 0 {
+				pos = skipSpaces(content, pos)
+				if pos >= len(content) || content[pos] != ',' {
+					break
+				}
+				pos = skipSpaces(content, pos+1)
+			}
+
+			start, end, value, ok := gradleStringArgument(content, pos)
+			if !ok {
+				break
+			}
+			args = append(args, value)
+			field.Start, field.End = start, end
+			pos = end
+		}
+		if len(args) != 3 {
+			continue
+		}
+
+		field.Type, field.Name, field.Value = args[0], args[1], args[2]
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func skipSpaces(content string, from int) int {
+	for from < len(content) && (content[from] == ' ' || content[from] == '\t') {
+		from++
+	}
+	return from
+}
+
+// gradleStringArgument parses the single or double quoted string literal starting at from.
+// It returns the offsets of the literal, including the quotes, and its unescaped value.
+func gradleStringArgument(content string, from int) (int, int, string, bool) {
+	if from >= len(content) || (content[from] != '"' && content[from] != '\'') || strings.HasPrefix(content[from:], `"""`) || strings.HasPrefix(content[from:], `'''`) {
+		return 0, 0, "", false
+	}
+
+	quote := content[from]
+	var value strings.Builder
+	for i := from + 1; i < len(content); i++ {
+		switch c := content[i]; c {
+		case '\\':
+			if i+1 >= len(content) {
+				return 0, 0, "", false
+			}
+			i++
+			switch content[i] {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			default:
+				value.WriteByte(content[i])
+			}
+		case '$':
+			if quote == '"' {
+				// interpolated value, can not be evaluated
+				return 0, 0, "", false
+			}
+			value.WriteByte(c)
+		case '\n':
+			return 0, 0, "", false
+		case quote:
+			return from, i + 1, value.String(), true
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return 0, 0, "", false
+}
+
+// gradleStringLiteral quotes the value with the given quote, escaping backslashes, the quote and the $ of interpolation.
+func gradleStringLiteral(value string, quote byte) string {
+	replacements := []string{`\`, `\\`, "\n", `\n`, string(quote), `\` + string(quote)}
+	if quote == '"' {
+		replacements = append(replacements, `$`, `\$`)
+	}
+	return string(quote) + strings.NewReplacer(replacements...).Replace(value) + string(quote)
+}
+
+// javaFieldValue returns the Java expression of the buildConfigField value for the field type.
+func javaFieldValue(fieldType, value string) (string, error) {
+	switch fieldType {
+	case "String":
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`, nil
+	case "int", "long", "Integer", "Long":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "", fmt.Errorf("value (%s) is not an integer", value)
+		}
+		if strings.EqualFold(fieldType, "long") {
+			return value + "L", nil
+		}
+		return value, nil
+	case "boolean", "Boolean":
+		if value != "true" && value != "false" {
+			return "", fmt.Errorf("value (%s) is not a boolean", value)
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("unsupported field type (%s)", fieldType)
+	}
+}
+
+// UpdateGradleFields sets the value of every buildConfigField or resValue declaration (method) with the given names,
+// keeping the quote style of each declaration. It returns the updated content and the updated fields.
+func UpdateGradleFields(content, method string, values map[string]string) (string, []GradleField, error) {
+	fields := FindGradleFields(content)
+
+	var updated []GradleField
+	for i := len(fields) - 1; i >= 0; i-- {
+		f := fields[i]
+		value, ok := values[f.Name]
+		if f.Method != method || !ok {
+			continue
+		}
+
+		if method == "buildConfigField" {
+			var err error
+			if value, err = javaFieldValue(f.Type, value); err != nil {
+				return "", nil, fmt.Errorf("buildConfigField (%s) on line %d: %s", f.Name, f.Line, err)
+			}
+		}
+
+		content = content[:f.Start] + gradleStringLiteral(value, content[f.Start]) + content[f.End:]
+		f.Value = value
+		updated = append([]GradleField{f}, updated...)
+	}
+	return content, updated, nil
+}
+
+// updateGradleFields sets the configured buildConfigField and resValue declarations of the updated build.gradle.
+// The values are <name>=<template> items, the templates are expanded with the final versions.
+func updateGradleFields(cfg config, res UpdateResult) (string, error) {
+	content := res.NewContent
+	for _, method := range []struct {
+		name  string
+		items []string
+	}{
+		{"buildConfigField", cfg.BuildConfigFields},
+		{"resValue", cfg.ResValues},
+	} {
+		values, err := parseNamedValues(method.name, method.items, versionPlaceholders(cfg, res))
+		if err != nil {
+			return "", err
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		var updated []GradleField
+		if content, updated, err = UpdateGradleFields(content, method.name, values); err != nil {
+			return "", err
+		}
+
+		found := map[string]bool{}
+		for _, f := range updated {
+			found[f.Name] = true
+			log.Printf("line %d: %s %s: %s", f.Line, method.name, f.Name, f.Value)
+		}
+		for name := range values {
+			if !found[name] {
+				log.Warnf("No %s declaration with literal arguments named (%s) found", method.name, name)
+			}
+		}
+	}
+	return content, nil
+}
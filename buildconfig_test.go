@@ -0,0 +1,101 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindGradleFields(t *testing.T) {
+	content := `android {
+    defaultConfig {
+        buildConfigField "String", "RELEASE_NAME", "\"1.2.0\""
+        buildConfigField 'int', 'RELEASE_CODE', '42'
+        // buildConfigField "String", "OLD", "\"1.0\""
+        buildConfigField("String", "GIT_SHA", "\"${gitSha}\"")
+        resValue("string", "app_version", "1.2.0")
+    }
+}`
+
+	var got []string
+	for _, f := range FindGradleFields(content) {
+		got = append(got, f.Method+" "+f.Type+" "+f.Name+" "+f.Value)
+	}
+	want := []string{
+		`buildConfigField String RELEASE_NAME "1.2.0"`,
+		`buildConfigField int RELEASE_CODE 42`,
+		`resValue string app_version 1.2.0`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindGradleFields() = %q, want %q", got, want)
+	}
+}
+
+func TestUpdateGradleFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		method  string
+		values  map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Groovy double quoted String field",
+			content: `buildConfigField "String", "RELEASE_NAME", "\"1.2.0\""`,
+			method:  "buildConfigField",
+			values:  map[string]string{"RELEASE_NAME": `1.3.0 "beta" $1\2`},
+			want:    `buildConfigField "String", "RELEASE_NAME", "\"1.3.0 \\\"beta\\\" \$1\\\\2\""`,
+		},
+		{
+			name:    "Groovy single quoted String field",
+			content: `buildConfigField 'String', 'RELEASE_NAME', '"1.2.0"'`,
+			method:  "buildConfigField",
+			values:  map[string]string{"RELEASE_NAME": "it's 1.3.0"},
+			want:    `buildConfigField 'String', 'RELEASE_NAME', '"it\'s 1.3.0"'`,
+		},
+		{
+			name:    "Kotlin DSL long field",
+			content: `buildConfigField("long", "RELEASE_CODE", "41L")`,
+			method:  "buildConfigField",
+			values:  map[string]string{"RELEASE_CODE": "42"},
+			want:    `buildConfigField("long", "RELEASE_CODE", "42L")`,
+		},
+		{
+			name:    "Invalid int field",
+			content: `buildConfigField "int", "RELEASE_CODE", "41"`,
+			method:  "buildConfigField",
+			values:  map[string]string{"RELEASE_CODE": "1.2.0"},
+			wantErr: true,
+		},
+		{
+			name:    "resValue",
+			content: "resValue \"string\", \"app_version\", \"1.2.0\"\nresValue \"string\", \"app_name\", \"App\"",
+			method:  "resValue",
+			values:  map[string]string{"app_version": "1.3.0"},
+			want:    "resValue \"string\", \"app_version\", \"1.3.0\"\nresValue \"string\", \"app_name\", \"App\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := UpdateGradleFields(tt.content, tt.method, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateGradleFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("UpdateGradleFields() = %s, want %s", got, tt.want)
+			}
+
+			if tt.wantErr || tt.method != "buildConfigField" {
+				return
+			}
+			// the updated declaration reads back as the Java literal of the value
+			fields := FindGradleFields(got)
+			if len(fields) != 1 {
+				t.Fatalf("FindGradleFields() = %+v", fields)
+			}
+			if want, _ := javaFieldValue(fields[0].Type, tt.values[fields[0].Name]); fields[0].Value != want {
+				t.Errorf("FindGradleFields() value = %s, want %s", fields[0].Value, want)
+			}
+		})
+	}
+}
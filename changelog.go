@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-steplib/steps-change-android-versioncode-and-versionname/conventionalcommit"
+)
+
+const changelogTitle = "# Changelog"
+
+// changelogSections are the changelog groups in order, keyed by conventional commit type.
+var changelogSections = []struct {
+	commitType string
+	title      string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"revert", "Reverts"},
+	{"refactor", "Code Refactoring"},
+	{"docs", "Documentation"},
+	{"", "Other Changes"},
+}
+
+// renderChangelog renders a Markdown changelog section of the version from the given commits.
+// Breaking changes are listed first, the other commits are grouped by their conventional type.
+func renderChangelog(versionName, versionCode string, date time.Time, commits []conventionalcommit.Commit) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s (%s) - %s\n", versionName, versionCode, date.Format("2006-01-02"))
+
+	writeSection := func(title string, commits []conventionalcommit.Commit) {
+		if len(commits) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", title)
+		for _, c := range commits {
+			fmt.Fprintf(&b, "- %s\n", changelogEntry(c))
+		}
+	}
+
+	var breaking []conventionalcommit.Commit
+	grouped := map[string][]conventionalcommit.Commit{}
+	for _, c := range commits {
+		if c.Breaking {
+			breaking = append(breaking, c)
+			continue
+		}
+		grouped[changelogSectionType(c.Type)] = append(grouped[changelogSectionType(c.Type)], c)
+	}
+
+	writeSection("⚠ BREAKING CHANGES", breaking)
+	for _, section := range changelogSections {
+		writeSection(section.title, grouped[section.commitType])
+	}
+	if len(commits) == 0 {
+		b.WriteString("\nNo changes.\n")
+	}
+	return b.String()
+}
+
+func changelogSectionType(commitType string) string {
+	for _, section := range changelogSections {
+		if section.commitType == commitType {
+			return commitType
+		}
+	}
+	return ""
+}
+
+func changelogEntry(c conventionalcommit.Commit) string {
+	description := c.Description
+	if c.Type == "" {
+		description = c.Subject
+	}
+	if c.Scope != "" {
+		description = fmt.Sprintf("**%s:** %s", c.Scope, description)
+	}
+
+	hash := c.Hash
+	if len(hash) > 7 {
+		hash = hash[:7]
+	}
+	if hash == "" {
+		return description
+	}
+	return fmt.Sprintf("%s (%s)", description, hash)
+}
+
+// prependChangelog inserts the section at the top of the changelog content, below its title.
+func prependChangelog(content, section string) string {
+	content = strings.TrimLeft(content, "\n")
+	if content == "" {
+		return changelogTitle + "\n\n" + section
+	}
+
+	if strings.HasPrefix(content, "# ") {
+		title, rest := content, ""
+		if i := strings.Index(content, "\n"); i >= 0 {
+			title, rest = content[:i], strings.TrimLeft(content[i+1:], "\n")
+		}
+		if rest == "" {
+			return title + "\n\n" + section
+		}
+		return title + "\n\n" + section + "\n" + rest
+	}
+	return section + "\n" + content
+}
+
+// replaceChangelogSection replaces the existing section of the versionName with the new one, so a re-run
+// does not add the same version twice. It returns false if the changelog has no section of the versionName.
+func replaceChangelogSection(content, versionName, section string) (string, bool) {
+	heading := fmt.Sprintf("## %s (", versionName)
+
+	start := -1
+	if strings.HasPrefix(content, heading) {
+		start = 0
+	} else if i := strings.Index(content, "\n"+heading); i >= 0 {
+		start = i + 1
+	}
+	if start < 0 {
+		return content, false
+	}
+
+	end := len(content)
+	if i := strings.Index(content[start+len(heading):], "\n## "); i >= 0 {
+		end = start + len(heading) + i + 1
+		section += "\n"
+	}
+	return content[:start] + section + content[end:], true
+}
+
+// generateChangelog prepends the changelog section of the commits since the previous version tag to the changelog file,
+// or replaces the section of the same version, and writes it to the deploy directory.
+func generateChangelog(cfg config, versionName, versionCode string) (map[string]string, error) {
+	pattern, err := regexp.Compile(cfg.GitTagPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid git tag pattern (%s): %s", cfg.GitTagPattern, err)
+	}
+
+	since := ""
+	tag, err := NewGitRepository(cfg.GitRepositoryPth).NearestTag(pattern)
+	switch {
+	case err == errNoMatchingTag:
+		log.Warnf("No previous version tag found, using every commit")
+	case err != nil:
+		return nil, err
+	default:
+		since = tag.Name
+	}
+
+	commits, err := conventionalcommit.Scan(cfg.GitRepositoryPth, since)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("%d commit(s) since: %s", len(commits), since)
+
+	section := renderChangelog(versionName, versionCode, time.Now(), commits)
+
+	changelogPth := cfg.ChangelogPth
+	if changelogPth == "" {
+		changelogPth = filepath.Join(cfg.GitRepositoryPth, "CHANGELOG.md")
+	}
+	content := ""
+	if exists, err := pathutil.IsPathExists(changelogPth); err != nil {
+		return nil, err
+	} else if exists {
+		if content, err = fileutil.ReadStringFromFile(changelogPth); err != nil {
+			return nil, err
+		}
+	}
+	updated, replaced := replaceChangelogSection(content, versionName, section)
+	if replaced {
+		log.Warnf("Changelog already contains version %s, replacing its section", versionName)
+	} else {
+		updated = prependChangelog(content, section)
+	}
+	if err := fileutil.WriteStringToFile(changelogPth, updated); err != nil {
+		return nil, err
+	}
+	log.Printf("changelog updated: %s", changelogPth)
+
+	outputs := map[string]string{"ANDROID_CHANGELOG": section}
+	if cfg.DeployDir != "" {
+		if err := pathutil.EnsureDirExist(cfg.DeployDir); err != nil {
+			return nil, err
+		}
+		deployPth := filepath.Join(cfg.DeployDir, "changelog.md")
+		if err := fileutil.WriteStringToFile(deployPth, section); err != nil {
+			return nil, err
+		}
+		log.Printf("changelog exported: %s", deployPth)
+		outputs["ANDROID_CHANGELOG_PATH"] = deployPth
+	}
+	return outputs, nil
+}
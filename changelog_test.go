@@ -0,0 +1,129 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-steplib/steps-change-android-versioncode-and-versionname/conventionalcommit"
+)
+
+func Test_renderChangelog(t *testing.T) {
+	date := time.Date(2021, time.March, 4, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		commits []conventionalcommit.Commit
+		want    string
+	}{
+		{
+			name: "Groups commits by type",
+			commits: []conventionalcommit.Commit{
+				conventionalcommit.Parse("1111111111", "fix: crash on start"),
+				conventionalcommit.Parse("2222222222", "feat(ui): dark mode"),
+				conventionalcommit.Parse("3333333333", "feat!: new login"),
+				conventionalcommit.Parse("4444444444", "chore: bump deps"),
+				conventionalcommit.Parse("5555555555", "Merge branch 'main'"),
+			},
+			want: `## 1.5.0 (42) - 2021-03-04
+
+### ⚠ BREAKING CHANGES
+
+- new login (3333333)
+
+### Features
+
+- **ui:** dark mode (2222222)
+
+### Bug Fixes
+
+- crash on start (1111111)
+
+### Other Changes
+
+- bump deps (4444444)
+- Merge branch 'main' (5555555)
+`,
+		},
+		{
+			name: "No commits",
+			want: "## 1.5.0 (42) - 2021-03-04\n\nNo changes.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderChangelog("1.5.0", "42", date, tt.commits); got != tt.want {
+				t.Errorf("renderChangelog() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_prependChangelog(t *testing.T) {
+	section := "## 1.5.0 (42) - 2021-03-04\n\n- fix\n"
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "New changelog",
+			content: "",
+			want:    "# Changelog\n\n## 1.5.0 (42) - 2021-03-04\n\n- fix\n",
+		},
+		{
+			name:    "Inserted below the title",
+			content: "# Changelog\n\n## 1.4.0 (41) - 2021-03-01\n\n- feat\n",
+			want:    "# Changelog\n\n## 1.5.0 (42) - 2021-03-04\n\n- fix\n\n## 1.4.0 (41) - 2021-03-01\n\n- feat\n",
+		},
+		{
+			name:    "Changelog without title",
+			content: "## 1.4.0 (41) - 2021-03-01\n",
+			want:    "## 1.5.0 (42) - 2021-03-04\n\n- fix\n\n## 1.4.0 (41) - 2021-03-01\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prependChangelog(tt.content, section); got != tt.want {
+				t.Errorf("prependChangelog() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_replaceChangelogSection(t *testing.T) {
+	section := "## 1.5.0 (43) - 2021-03-05\n\n- fix\n"
+
+	tests := []struct {
+		name         string
+		content      string
+		want         string
+		wantReplaced bool
+	}{
+		{
+			name:         "Latest section replaced",
+			content:      "# Changelog\n\n## 1.5.0 (42) - 2021-03-04\n\n- feat\n\n## 1.4.0 (41) - 2021-03-01\n\n- feat\n",
+			want:         "# Changelog\n\n## 1.5.0 (43) - 2021-03-05\n\n- fix\n\n## 1.4.0 (41) - 2021-03-01\n\n- feat\n",
+			wantReplaced: true,
+		},
+		{
+			name:         "Last section replaced",
+			content:      "## 1.5.0 (42) - 2021-03-04\n\n- feat\n",
+			want:         "## 1.5.0 (43) - 2021-03-05\n\n- fix\n",
+			wantReplaced: true,
+		},
+		{
+			name:    "Other version prefix is not replaced",
+			content: "# Changelog\n\n## 1.5.0-beta (42) - 2021-03-04\n\n- feat\n",
+			want:    "# Changelog\n\n## 1.5.0-beta (42) - 2021-03-04\n\n- feat\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, replaced := replaceChangelogSection(tt.content, "1.5.0", section)
+			if got != tt.want || replaced != tt.wantReplaced {
+				t.Errorf("replaceChangelogSection() = %q, %v, want %q, %v", got, replaced, tt.want, tt.wantReplaced)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Command versioncode-allocator serves strictly increasing versionCodes per app to concurrent builds.
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-change-android-versioncode-and-versionname/allocator"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	storePth := flag.String("store", "versioncodes.json", "path of the JSON store file")
+	lockTimeout := flag.Duration("lock-timeout", 10*time.Second, "how long to wait for the store lock")
+	flag.Parse()
+
+	store := allocator.NewFileStore(*storePth, *lockTimeout)
+
+	log.Infof("Serving versionCode leases on %s, store: %s", *addr, *storePth)
+	if err := http.ListenAndServe(*addr, allocator.NewHandler(store)); err != nil {
+		log.Errorf("Server failed: %s", err)
+		os.Exit(1)
+	}
+}
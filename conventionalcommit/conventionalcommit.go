@@ -0,0 +1,169 @@
+// Package conventionalcommit reads commits from a local git repository and classifies them
+// according to the Conventional Commits specification (https://www.conventionalcommits.org).
+package conventionalcommit
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+)
+
+const (
+	fieldSeparator  = "\x1f"
+	recordSeparator = "\x1e"
+)
+
+var (
+	headerRegexp         = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^)]*)\))?(!)?: (.+)$`)
+	breakingFooterRegexp = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+	semverRegexp         = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)`)
+)
+
+// Bump is the semantic version component to increment.
+type Bump int
+
+// Bump levels, ordered by significance.
+const (
+	None Bump = iota
+	Patch
+	Minor
+	Major
+)
+
+func (b Bump) String() string {
+	switch b {
+	case Patch:
+		return "patch"
+	case Minor:
+		return "minor"
+	case Major:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// Commit is a parsed commit message.
+type Commit struct {
+	Hash        string
+	Subject     string
+	Body        string
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+}
+
+// Bump returns the version bump the commit requires.
+func (c Commit) Bump() Bump {
+	switch {
+	case c.Breaking:
+		return Major
+	case c.Type == "feat":
+		return Minor
+	case c.Type == "fix":
+		return Patch
+	default:
+		return None
+	}
+}
+
+// Parse parses a commit message. Messages not following the specification have an empty Type.
+func Parse(hash, message string) Commit {
+	message = strings.TrimSpace(message)
+	subject, body := message, ""
+	if i := strings.Index(message, "\n"); i >= 0 {
+		subject, body = message[:i], strings.TrimSpace(message[i+1:])
+	}
+
+	c := Commit{Hash: hash, Subject: subject, Body: body}
+	if match := headerRegexp.FindStringSubmatch(subject); match != nil {
+		c.Type = strings.ToLower(match[1])
+		c.Scope = match[2]
+		c.Breaking = match[3] == "!"
+		c.Description = match[4]
+	}
+	if breakingFooterRegexp.MatchString(body) {
+		c.Breaking = true
+	}
+	return c
+}
+
+// Classify returns the most significant bump of the commits and the commits which justify it.
+func Classify(commits []Commit) (Bump, []Commit) {
+	bump := None
+	var justifying []Commit
+	for _, c := range commits {
+		switch b := c.Bump(); {
+		case b > bump:
+			bump = b
+			justifying = []Commit{c}
+		case b == bump && b != None:
+			justifying = append(justifying, c)
+		}
+	}
+	return bump, justifying
+}
+
+// Apply increments the given major.minor.patch version, any prerelease or build suffix is dropped.
+func Apply(version string, bump Bump) (string, error) {
+	match := semverRegexp.FindStringSubmatch(version)
+	if match == nil {
+		return "", fmt.Errorf("version (%s) is not a major.minor.patch version", version)
+	}
+
+	var parts [3]int
+	for i := range parts {
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return "", err
+		}
+		parts[i] = n
+	}
+
+	switch bump {
+	case Major:
+		parts = [3]int{parts[0] + 1, 0, 0}
+	case Minor:
+		parts = [3]int{parts[0], parts[1] + 1, 0}
+	case Patch:
+		parts[2]++
+	default:
+		return match[0], nil
+	}
+	return fmt.Sprintf("%d.%d.%d", parts[0], parts[1], parts[2]), nil
+}
+
+// Scan reads the commits of the local git repository in dir which are reachable from HEAD but not from since,
+// newest first. If since is empty, every commit reachable from HEAD is returned.
+func Scan(dir, since string) ([]Commit, error) {
+	revision := "HEAD"
+	if since != "" {
+		revision = since + "..HEAD"
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := command.New("git", "log", "--format=%H"+fieldSeparator+"%B"+recordSeparator, revision).
+		SetDir(dir).SetStdout(&stdout).SetStderr(&stderr)
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %s: %s", cmd.PrintableCommandArgs(), err, strings.TrimSpace(stderr.String()))
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(stdout.String(), recordSeparator) {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, fieldSeparator, 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected git log record: %s", record)
+		}
+		commits = append(commits, Parse(fields[0], fields[1]))
+	}
+	return commits, nil
+}
@@ -0,0 +1,137 @@
+package conventionalcommit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/command"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    Commit
+	}{
+		{
+			name:    "Type and description",
+			message: "feat: add login",
+			want:    Commit{Subject: "feat: add login", Type: "feat", Description: "add login"},
+		},
+		{
+			name:    "Scope and breaking marker",
+			message: "fix(api)!: drop v1 endpoints",
+			want:    Commit{Subject: "fix(api)!: drop v1 endpoints", Type: "fix", Scope: "api", Description: "drop v1 endpoints", Breaking: true},
+		},
+		{
+			name:    "Breaking change footer",
+			message: "refactor: rename fields\n\nBREAKING CHANGE: the config format changed",
+			want:    Commit{Subject: "refactor: rename fields", Body: "BREAKING CHANGE: the config format changed", Type: "refactor", Description: "rename fields", Breaking: true},
+		},
+		{
+			name:    "Not a conventional commit",
+			message: "Merge branch 'main'",
+			want:    Commit{Subject: "Merge branch 'main'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse("", tt.message); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	feat := Parse("1", "feat: a")
+	fix := Parse("2", "fix: b")
+	feat2 := Parse("3", "feat(ui): c")
+	breaking := Parse("4", "chore!: d")
+	docs := Parse("5", "docs: e")
+
+	tests := []struct {
+		name           string
+		commits        []Commit
+		wantBump       Bump
+		wantJustifying []Commit
+	}{
+		{name: "No commits", wantBump: None},
+		{name: "No releasable commits", commits: []Commit{docs}, wantBump: None},
+		{name: "Fix", commits: []Commit{docs, fix}, wantBump: Patch, wantJustifying: []Commit{fix}},
+		{name: "Features win over fixes", commits: []Commit{feat, fix, feat2}, wantBump: Minor, wantJustifying: []Commit{feat, feat2}},
+		{name: "Breaking change", commits: []Commit{feat, breaking, fix}, wantBump: Major, wantJustifying: []Commit{breaking}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bump, justifying := Classify(tt.commits)
+			if bump != tt.wantBump {
+				t.Errorf("Classify() bump = %v, want %v", bump, tt.wantBump)
+			}
+			if !reflect.DeepEqual(justifying, tt.wantJustifying) {
+				t.Errorf("Classify() justifying = %v, want %v", justifying, tt.wantJustifying)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		version string
+		bump    Bump
+		want    string
+		wantErr bool
+	}{
+		{version: "1.4.2", bump: Patch, want: "1.4.3"},
+		{version: "1.4.2", bump: Minor, want: "1.5.0"},
+		{version: "1.4.2", bump: Major, want: "2.0.0"},
+		{version: "1.4.2-rc.1", bump: None, want: "1.4.2"},
+		{version: "1.4", bump: Patch, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.bump.String(), func(t *testing.T) {
+			got, err := Apply(tt.version, tt.bump)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		out, err := command.New("git", args...).SetDir(dir).AppendEnvs(
+			"GIT_AUTHOR_NAME=tester", "GIT_AUTHOR_EMAIL=tester@example.com",
+			"GIT_COMMITTER_NAME=tester", "GIT_COMMITTER_EMAIL=tester@example.com",
+		).RunAndReturnTrimmedCombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %s: %s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "chore: initial")
+	git("tag", "v1.0.0")
+	git("commit", "-q", "--allow-empty", "-m", "fix: crash on start")
+	git("commit", "-q", "--allow-empty", "-m", "feat(ui): dark mode\n\nBREAKING CHANGE: new theme API")
+
+	commits, err := Scan(dir, "v1.0.0")
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	var subjects []string
+	for _, c := range commits {
+		subjects = append(subjects, c.Subject)
+	}
+	if want := []string{"feat(ui): dark mode", "fix: crash on start"}; !reflect.DeepEqual(subjects, want) {
+		t.Errorf("Scan() subjects = %v, want %v", subjects, want)
+	}
+	if !commits[0].Breaking || len(commits[0].Hash) != 40 {
+		t.Errorf("Scan() first commit = %+v, want breaking commit with full hash", commits[0])
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// versionCodeMax is the greatest value Google Play allows for versionCode.
+	versionCodeMax = 2100000000
+	// sequenceToken marks the digits of the same-day collision sequence in a date format.
+	sequenceToken = 'n'
+)
+
+// dateTokens are the supported date format placeholders, longest first so that yyyy wins over yy.
+var dateTokens = []struct {
+	token  string
+	render func(t time.Time) string
+}{
+	{"yyyy", func(t time.Time) string { return fmt.Sprintf("%04d", t.Year()) }},
+	{"yy", func(t time.Time) string { return fmt.Sprintf("%02d", t.Year()%100) }},
+	{"MM", func(t time.Time) string { return fmt.Sprintf("%02d", int(t.Month())) }},
+	{"DDD", func(t time.Time) string { return fmt.Sprintf("%03d", t.YearDay()) }},
+	{"dd", func(t time.Time) string { return fmt.Sprintf("%02d", t.Day()) }},
+	{"HH", func(t time.Time) string { return fmt.Sprintf("%02d", t.Hour()) }},
+	{"mm", func(t time.Time) string { return fmt.Sprintf("%02d", t.Minute()) }},
+}
+
+// DateVersionCode generates versionCodes from the current date and time.
+type DateVersionCode struct {
+	format   string
+	location *time.Location
+	now      func() time.Time
+}
+
+// NewDateVersionCode constructs a new DateVersionCode.
+// The format is built from the yyyy, yy, MM, DDD, dd, HH and mm placeholders, literal digits
+// and an optional trailing run of n characters which holds the same-day collision sequence (e.g. yyDDDnnn).
+func NewDateVersionCode(format string, location *time.Location, now func() time.Time) DateVersionCode {
+	return DateVersionCode{format: format, location: location, now: now}
+}
+
+// Generate returns the versionCode for the current time.
+// If currentVersionCode was generated for the same date prefix, the sequence suffix is incremented.
+// currentVersionCode includes the versionCodeOffset, which is subtracted before the comparison.
+func (d DateVersionCode) Generate(currentVersionCode string, versionCodeOffset int) (int, error) {
+	prefix, sequenceDigits, err := d.render(d.now().In(d.location))
+	if err != nil {
+		return 0, err
+	}
+
+	sequence := 0
+	if current, err := strconv.Atoi(currentVersionCode); err == nil && current-versionCodeOffset > 0 {
+		current -= versionCodeOffset
+		padded := fmt.Sprintf("%0*d", len(prefix)+sequenceDigits, current)
+		if sequenceDigits > 0 && strings.HasPrefix(padded, prefix) {
+			currentSequence, err := strconv.Atoi(padded[len(prefix):])
+			if err != nil {
+				return 0, err
+			}
+			sequence = currentSequence + 1
+		}
+
+		if sequenceDigits == 0 && padded == prefix {
+			return 0, fmt.Errorf("generated versionCode (%s) equals the current one, add sequence digits (n) to the format (%s)", prefix, d.format)
+		}
+	}
+
+	codeStr := prefix
+	if sequenceDigits > 0 {
+		sequenceStr := fmt.Sprintf("%0*d", sequenceDigits, sequence)
+		if len(sequenceStr) > sequenceDigits {
+			return 0, fmt.Errorf("sequence (%d) overflows the %d sequence digit(s) of the format (%s)", sequence, sequenceDigits, d.format)
+		}
+		codeStr += sequenceStr
+	}
+
+	code, err := strconv.Atoi(codeStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid versionCode (%s) generated from format (%s): %s", codeStr, d.format, err)
+	}
+	if code <= 0 || code > versionCodeMax {
+		return 0, fmt.Errorf("generated versionCode (%d) is out of range ]0..%d]", code, versionCodeMax)
+	}
+	return code, nil
+}
+
+// render substitutes the date placeholders of the format and returns the number of sequence digits.
+func (d DateVersionCode) render(t time.Time) (string, int, error) {
+	format := strings.TrimRight(d.format, string(sequenceToken))
+	sequenceDigits := len(d.format) - len(format)
+	if format == "" {
+		return "", 0, fmt.Errorf("date format (%s) has no date placeholders", d.format)
+	}
+
+	var b strings.Builder
+	for rest := format; rest != ""; {
+		matched := false
+		for _, dt := range dateTokens {
+			if strings.HasPrefix(rest, dt.token) {
+				b.WriteString(dt.render(t))
+				rest = rest[len(dt.token):]
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		if rest[0] < '0' || rest[0] > '9' {
+			return "", 0, fmt.Errorf("invalid character (%c) in date format (%s), sequence digits (n) are only allowed at the end", rest[0], d.format)
+		}
+		b.WriteByte(rest[0])
+		rest = rest[1:]
+	}
+
+	return b.String(), sequenceDigits, nil
+}
+
+// generateDateVersionCode generates the versionCode from the configured date format.
+// With the offset, it must not exceed the Google Play ceiling.
+func generateDateVersionCode(cfg config, currentVersionCode string) (int, error) {
+	if cfg.VersionCodeDateFmt == "" {
+		return 0, fmt.Errorf("version_code_date_format is required for the date versionCode source")
+	}
+
+	location, err := time.LoadLocation(cfg.VersionCodeTimeZone)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time zone (%s): %s", cfg.VersionCodeTimeZone, err)
+	}
+
+	code, err := NewDateVersionCode(cfg.VersionCodeDateFmt, location, time.Now).Generate(currentVersionCode, cfg.VersionCodeOffset)
+	if err != nil {
+		return 0, err
+	}
+	if code+cfg.VersionCodeOffset > versionCodeMax {
+		return 0, fmt.Errorf("generated versionCode (%d) with offset (%d) exceeds %d", code, cfg.VersionCodeOffset, versionCodeMax)
+	}
+	return code, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateVersionCode_Generate(t *testing.T) {
+	now := func() time.Time { return time.Date(2021, time.March, 4, 23, 30, 0, 0, time.UTC) }
+	budapest := time.FixedZone("CET", 60*60)
+
+	tests := []struct {
+		name               string
+		format             string
+		location           *time.Location
+		currentVersionCode string
+		versionCodeOffset  int
+
+		want    int
+		wantErr bool
+	}{
+		{
+			name:     "Hourly format",
+			format:   "yyMMddHH",
+			location: time.UTC,
+			want:     21030423,
+		},
+		{
+			name:     "Time zone is applied",
+			format:   "yyMMddHH",
+			location: budapest,
+			want:     21030500,
+		},
+		{
+			name:     "Day of year with sequence starts from 0",
+			format:   "yyDDDnnn",
+			location: time.UTC,
+			want:     21063000,
+		},
+		{
+			name:               "Same day collision increments the sequence",
+			format:             "yyDDDnnn",
+			location:           time.UTC,
+			currentVersionCode: "21063004",
+			want:               21063005,
+		},
+		{
+			name:               "Previous day resets the sequence",
+			format:             "yyDDDnnn",
+			location:           time.UTC,
+			currentVersionCode: "21062999",
+			want:               21063000,
+		},
+		{
+			name:               "Offset is subtracted from the current versionCode",
+			format:             "yyDDDnnn",
+			location:           time.UTC,
+			currentVersionCode: "21068000",
+			versionCodeOffset:  5000,
+			want:               21063001,
+		},
+		{
+			name:               "Previous day with offset resets the sequence",
+			format:             "yyDDDnnn",
+			location:           time.UTC,
+			currentVersionCode: "21067999",
+			versionCodeOffset:  5000,
+			want:               21063000,
+		},
+		{
+			name:               "Non numeric current versionCode is ignored",
+			format:             "yyDDDnnn",
+			location:           time.UTC,
+			currentVersionCode: "rootProject.ext.versionCode",
+			want:               21063000,
+		},
+		{
+			name:               "Literal digits are kept",
+			format:             "1yyMMddn",
+			location:           time.UTC,
+			currentVersionCode: "12103041",
+			want:               12103042,
+		},
+		{
+			name:               "Sequence overflow",
+			format:             "yyDDDn",
+			location:           time.UTC,
+			currentVersionCode: "210639",
+			wantErr:            true,
+		},
+		{
+			name:               "Collision without sequence digits",
+			format:             "yyMMddHH",
+			location:           time.UTC,
+			currentVersionCode: "21030423",
+			wantErr:            true,
+		},
+		{
+			name:     "Exceeds the Google Play limit",
+			format:   "yyMMddHHmm",
+			location: time.UTC,
+			wantErr:  true,
+		},
+		{
+			name:     "Sequence digits only at the end",
+			format:   "yynMMdd",
+			location: time.UTC,
+			wantErr:  true,
+		},
+		{
+			name:     "No date placeholders",
+			format:   "nnn",
+			location: time.UTC,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDateVersionCode(tt.format, tt.location, now)
+			got, err := d.Generate(tt.currentVersionCode, tt.versionCodeOffset)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DateVersionCode.Generate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("DateVersionCode.Generate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+var errIntegerOverflow = errors.New("integer overflow")
+
+type expressionToken struct {
+	// kind is number, variable or the operator or parenthesis character.
+	kind  string
+	value string
+	pos   int
+}
+
+// tokenizeExpression splits the expression into integer literals, variables ($NAME, ${NAME} or NAME),
+// the + - * / % operators and parentheses.
+func tokenizeExpression(expression string) ([]expressionToken, error) {
+	var tokens []expressionToken
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("+-*/%()", r):
+			tokens = append(tokens, expressionToken{kind: string(r), value: string(r), pos: i})
+			i++
+		case isASCIIDigit(r):
+			start := i
+			for i < len(runes) && isASCIIDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, expressionToken{kind: "number", value: string(runes[start:i]), pos: start})
+		case r == '$' || r == '_' || unicode.IsLetter(r):
+			start := i
+			if r == '$' {
+				i++
+			}
+			braced := i < len(runes) && runes[i] == '{'
+			if braced {
+				i++
+			}
+			nameStart := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || isASCIIDigit(runes[i])) {
+				i++
+			}
+			name := string(runes[nameStart:i])
+			if braced {
+				if i >= len(runes) || runes[i] != '}' {
+					return nil, fmt.Errorf("unterminated variable at position %d", start+1)
+				}
+				i++
+			}
+			if name == "" {
+				return nil, fmt.Errorf("missing variable name at position %d", start+1)
+			}
+			tokens = append(tokens, expressionToken{kind: "variable", value: name, pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected character (%c) at position %d", r, i+1)
+		}
+	}
+	return tokens, nil
+}
+
+// isASCIIDigit reports whether the rune is 0-9, other Unicode digits are not integer literals.
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// expressionParser is a recursive descent parser of integer expressions:
+//
+//	expression = term { ("+" | "-") term }
+//	term       = factor { ("*" | "/" | "%") factor }
+//	factor     = [ "-" | "+" ] ( number | variable | "(" expression ")" )
+type expressionParser struct {
+	tokens []expressionToken
+	pos    int
+	lookup func(name string) (int64, error)
+}
+
+// EvaluateExpression evaluates an integer expression of + - * / % operators, parentheses, integer literals and variables,
+// resolved by lookup, with overflow checked int64 arithmetic.
+func EvaluateExpression(expression string, lookup func(name string) (int64, error)) (int64, error) {
+	tokens, err := tokenizeExpression(expression)
+	if err != nil {
+		return 0, err
+	}
+	if len(tokens) == 0 {
+		return 0, errors.New("empty expression")
+	}
+
+	p := expressionParser{tokens: tokens, lookup: lookup}
+	value, err := p.expression()
+	if err != nil {
+		return 0, err
+	}
+	if p.pos < len(p.tokens) {
+		return 0, fmt.Errorf("unexpected (%s) at position %d", p.tokens[p.pos].value, p.tokens[p.pos].pos+1)
+	}
+	return value, nil
+}
+
+func (p *expressionParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].kind
+	}
+	return ""
+}
+
+func (p *expressionParser) expression() (int64, error) {
+	value, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for op := p.peek(); op == "+" || op == "-"; op = p.peek() {
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return 0, err
+		}
+		if value, err = applyOperator(op, value, right); err != nil {
+			return 0, err
+		}
+	}
+	return value, nil
+}
+
+func (p *expressionParser) term() (int64, error) {
+	value, err := p.factor()
+	if err != nil {
+		return 0, err
+	}
+	for op := p.peek(); op == "*" || op == "/" || op == "%"; op = p.peek() {
+		p.pos++
+		right, err := p.factor()
+		if err != nil {
+			return 0, err
+		}
+		if value, err = applyOperator(op, value, right); err != nil {
+			return 0, err
+		}
+	}
+	return value, nil
+}
+
+func (p *expressionParser) factor() (int64, error) {
+	if p.pos >= len(p.tokens) {
+		return 0, errors.New("unexpected end of expression")
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+	switch token.kind {
+	case "-", "+":
+		value, err := p.factor()
+		if err != nil {
+			return 0, err
+		}
+		if token.kind == "+" {
+			return value, nil
+		}
+		return applyOperator("-", 0, value)
+	case "number":
+		value, err := strconv.ParseInt(token.value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("number (%s) at position %d: %s", token.value, token.pos+1, errIntegerOverflow)
+		}
+		return value, nil
+	case "variable":
+		value, err := p.lookup(token.value)
+		if err != nil {
+			return 0, fmt.Errorf("variable (%s): %s", token.value, err)
+		}
+		return value, nil
+	case "(":
+		value, err := p.expression()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ")" {
+			return 0, fmt.Errorf("missing closing parenthesis of position %d", token.pos+1)
+		}
+		p.pos++
+		return value, nil
+	default:
+		return 0, fmt.Errorf("unexpected (%s) at position %d", token.value, token.pos+1)
+	}
+}
+
+// applyOperator applies the binary operator, failing on overflow and division by zero.
+func applyOperator(op string, a, b int64) (int64, error) {
+	switch op {
+	case "+":
+		if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+			return 0, errIntegerOverflow
+		}
+		return a + b, nil
+	case "-":
+		if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+			return 0, errIntegerOverflow
+		}
+		return a - b, nil
+	case "*":
+		if a == 0 || b == 0 {
+			return 0, nil
+		}
+		c := a * b
+		if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+			return 0, errIntegerOverflow
+		}
+		return c, nil
+	case "/", "%":
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		if a == math.MinInt64 && b == -1 {
+			return 0, errIntegerOverflow
+		}
+		if op == "/" {
+			return a / b, nil
+		}
+		return a % b, nil
+	default:
+		return 0, fmt.Errorf("unknown operator (%s)", op)
+	}
+}
+
+// evaluateNewVersionCode evaluates the new_version_code expression, which can refer to the current versionCode as current,
+// the build number as build and environment variables. The result, with the offset, must not exceed the Google Play ceiling.
+func evaluateNewVersionCode(cfg config, currentVersionCode string) (int, error) {
+	lookup := func(name string) (int64, error) {
+		value, description := "", ""
+		switch name {
+		case "current":
+			value, description = currentVersionCode, "current versionCode"
+		case "build":
+			value, description = cfg.BuildNumber, "build_number"
+		default:
+			var ok bool
+			if value, ok = os.LookupEnv(name); !ok {
+				return 0, errors.New("environment variable is not set")
+			}
+			description = "environment variable"
+		}
+
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s (%s) is not an integer", description, value)
+		}
+		return n, nil
+	}
+
+	code, err := EvaluateExpression(cfg.NewVersionCode, lookup)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expression (%s): %s", cfg.NewVersionCode, err)
+	}
+	if _, err := strconv.Atoi(cfg.NewVersionCode); err != nil {
+		log.Printf("new_version_code (%s) = %d", cfg.NewVersionCode, code)
+	}
+
+	if code <= 0 || code > math.MaxInt32 {
+		return 0, fmt.Errorf("versionCode (%d) is out of range ]0..%d]", code, math.MaxInt32)
+	}
+	if final := code + int64(cfg.VersionCodeOffset); final <= 0 || final > versionCodeMax {
+		return 0, fmt.Errorf("versionCode (%d) with offset (%d) is out of the Google Play range ]0..%d]", code, cfg.VersionCodeOffset, versionCodeMax)
+	}
+	return int(code), nil
+}
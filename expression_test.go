@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	variables := map[string]int64{"current": 41, "build": 123, "BITRISE_BUILD_NUMBER": 123, "MAX": 9223372036854775807}
+	lookup := func(name string) (int64, error) {
+		if value, ok := variables[name]; ok {
+			return value, nil
+		}
+		return 0, fmt.Errorf("not set")
+	}
+
+	tests := []struct {
+		expression string
+		want       int64
+		wantErr    bool
+	}{
+		{expression: "42", want: 42},
+		{expression: "$BITRISE_BUILD_NUMBER * 10 + 3000000", want: 3001230},
+		{expression: "${BITRISE_BUILD_NUMBER}*10+3000000", want: 3001230},
+		{expression: "current + 1", want: 42},
+		{expression: "(build - 3) / 4 % 7", want: 2},
+		{expression: "-(build - 200)", want: 77},
+		{expression: "2 * (3 + 4) * 5", want: 70},
+		{expression: "MAX + 1", wantErr: true},
+		{expression: "MAX * 2", wantErr: true},
+		{expression: "-MAX - 2", wantErr: true},
+		{expression: "99999999999999999999", wantErr: true},
+		{expression: "build / (current - 41)", wantErr: true},
+		{expression: "UNKNOWN + 1", wantErr: true},
+		{expression: "(1 + 2", wantErr: true},
+		{expression: "1 + 2)", wantErr: true},
+		{expression: "1 +", wantErr: true},
+		{expression: "2 ** 3", wantErr: true},
+		{expression: "1.5", wantErr: true},
+		{expression: "١٢", wantErr: true},
+		{expression: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := EvaluateExpression(tt.expression, lookup)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvaluateExpression() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateExpression() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_evaluateNewVersionCode(t *testing.T) {
+	if err := os.Setenv("EXPRESSION_TEST_CODE", "7"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Unsetenv("EXPRESSION_TEST_CODE"); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	tests := []struct {
+		name              string
+		newVersionCode    string
+		versionCodeOffset int
+		want              int
+		wantErr           string
+	}{
+		{name: "Literal", newVersionCode: "42", want: 42},
+		{name: "Precedence", newVersionCode: "current + build * 2 - $EXPRESSION_TEST_CODE % 4", want: 126},
+		{name: "Parentheses", newVersionCode: "(current + build) * 2", want: 170},
+		{name: "Unknown environment variable", newVersionCode: "EXPRESSION_TEST_UNSET + 1", wantErr: "invalid expression (EXPRESSION_TEST_UNSET + 1): variable (EXPRESSION_TEST_UNSET): environment variable is not set"},
+		{name: "Division by zero", newVersionCode: "build / (current - 41)", wantErr: "invalid expression (build / (current - 41)): division by zero"},
+		{name: "Overflow", newVersionCode: "9223372036854775807 + current", wantErr: "invalid expression (9223372036854775807 + current): integer overflow"},
+		{name: "Non-ASCII digits", newVersionCode: "١٢", wantErr: "invalid expression (١٢): unexpected character (١) at position 1"},
+		{name: "Zero", newVersionCode: "current - 41", wantErr: "versionCode (0) is out of range ]0..2147483647]"},
+		{name: "Over int32", newVersionCode: "2147483648", wantErr: "versionCode (2147483648) is out of range ]0..2147483647]"},
+		{name: "Google Play limit", newVersionCode: "2099999000", versionCodeOffset: 1000, want: 2099999000},
+		{name: "Over the Google Play limit with offset", newVersionCode: "2099999001", versionCodeOffset: 1000, wantErr: "versionCode (2099999001) with offset (1000) is out of the Google Play range ]0..2100000000]"},
+		{name: "Negative offset", newVersionCode: "10", versionCodeOffset: -10, wantErr: "versionCode (10) with offset (-10) is out of the Google Play range ]0..2100000000]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config{NewVersionCode: tt.newVersionCode, VersionCodeOffset: tt.versionCodeOffset, BuildNumber: "44"}
+			got, err := evaluateNewVersionCode(cfg, "41")
+			if gotErr := fmt.Sprint(err); (err != nil || tt.wantErr != "") && gotErr != tt.wantErr {
+				t.Fatalf("evaluateNewVersionCode() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("evaluateNewVersionCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
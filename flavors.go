@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+var (
+	flavorDimensionsRegexp = regexp.MustCompile(`(?m)^\s*flavorDimensions\b(.*)$`)
+	flavorDimensionRegexp  = regexp.MustCompile(`(?m)^\s*dimension\s*(?:=\s*|\(\s*)?["']([^"']+)["']`)
+)
+
+// ProductFlavor is an entry of the android { productFlavors { } } block.
+type ProductFlavor struct {
+	Name      string
+	Dimension string
+	// Body is the content of the flavor block, Start and End are its offsets in the build.gradle content.
+	Body  string
+	Start int
+	End   int
+}
+
+// ParseFlavorDimensions returns the flavor dimensions declared with flavorDimensions "store", "tier"
+// or flavorDimensions += listOf("store", "tier"), in priority order.
+func ParseFlavorDimensions(content string) []string {
+	var dimensions []string
+	for _, match := range flavorDimensionsRegexp.FindAllStringSubmatch(content, -1) {
+		for _, value := range quotedStringRegexp.FindAllStringSubmatch(match[1], -1) {
+			dimensions = append(dimensions, value[1])
+		}
+	}
+	return dimensions
+}
+
+// ParseProductFlavors returns the product flavors of the build.gradle content.
+// Flavors without a dimension belong to the only dimension, if a single one is declared.
+func ParseProductFlavors(content string) []ProductFlavor {
+	dimensions := ParseFlavorDimensions(content)
+
+	var flavors []ProductFlavor
+	for _, block := range findGradleBlocks(content, "android", "productFlavors") {
+		for _, b := range gradleBlocks(block.Body) {
+			flavor := ProductFlavor{Name: b.Name, Body: b.Body, Start: block.Start + b.Start, End: block.Start + b.End}
+			if match := flavorDimensionRegexp.FindStringSubmatch(b.Body); match != nil {
+				flavor.Dimension = match[1]
+			} else if len(dimensions) == 1 {
+				flavor.Dimension = dimensions[0]
+			}
+			flavors = append(flavors, flavor)
+		}
+	}
+	return flavors
+}
+
+// FlavorCombinations returns every combination of one flavor per dimension, the flavors of a combination
+// are in dimension order, as Gradle creates the variants.
+func FlavorCombinations(dimensions []string, flavors []ProductFlavor) ([][]ProductFlavor, error) {
+	if len(flavors) == 0 {
+		return nil, nil
+	}
+
+	byDimension := map[string][]ProductFlavor{}
+	for _, f := range flavors {
+		if !sliceContains(dimensions, f.Dimension) {
+			return nil, fmt.Errorf("flavor (%s) has undeclared dimension (%s)", f.Name, f.Dimension)
+		}
+		byDimension[f.Dimension] = append(byDimension[f.Dimension], f)
+	}
+
+	combinations := [][]ProductFlavor{{}}
+	for _, dimension := range dimensions {
+		if len(byDimension[dimension]) == 0 {
+			return nil, fmt.Errorf("no flavor in dimension (%s)", dimension)
+		}
+
+		var next [][]ProductFlavor
+		for _, combination := range combinations {
+			for _, f := range byDimension[dimension] {
+				next = append(next, append(append([]ProductFlavor{}, combination...), f))
+			}
+		}
+		combinations = next
+	}
+	return combinations, nil
+}
+
+// variantName joins the flavor and build type names the way Gradle names variants, for example paidRelease.
+func variantName(names ...string) string {
+	name := ""
+	for _, n := range names {
+		if n == "" {
+			continue
+		}
+		if name != "" {
+			n = strings.ToUpper(n[:1]) + n[1:]
+		}
+		name += n
+	}
+	return name
+}
+
+// FlavorSlot maps the flavors of a dimension to the digit of the dimension's slot.
+type FlavorSlot struct {
+	Dimension string
+	Digits    map[string]int
+}
+
+// FlavorEncoding encodes the flavors of a variant into the leading digits of its versionCode,
+// one digit slot per dimension followed by the base versionCode.
+type FlavorEncoding struct {
+	slots []FlavorSlot
+	// width is the number of digits the base versionCode is padded to after the slots.
+	width int
+}
+
+// ParseFlavorEncoding parses a pipe separated list of <dimension>:<flavor>=<digit>,... slots, most significant first,
+// for example store:play=1,amazon=2|tier:free=0,paid=1.
+func ParseFlavorEncoding(spec string, width int) (FlavorEncoding, error) {
+	e := FlavorEncoding{width: width}
+	for _, item := range strings.Split(spec, "|") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return FlavorEncoding{}, fmt.Errorf("invalid flavor slot (%s), expected <dimension>:<flavor>=<digit>,...", item)
+		}
+
+		slot := FlavorSlot{Dimension: parts[0], Digits: map[string]int{}}
+		for _, mapping := range strings.Split(parts[1], ",") {
+			kv := strings.Split(strings.TrimSpace(mapping), "=")
+			if len(kv) != 2 || kv[0] == "" {
+				return FlavorEncoding{}, fmt.Errorf("invalid flavor digit (%s) in slot (%s), expected <flavor>=<digit>", mapping, item)
+			}
+			digit, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return FlavorEncoding{}, fmt.Errorf("invalid digit (%s) of flavor (%s): %s", kv[1], kv[0], err)
+			}
+			if digit < 0 || digit > 9 {
+				return FlavorEncoding{}, fmt.Errorf("digit (%d) of flavor (%s) overflows the slot of dimension (%s)", digit, kv[0], slot.Dimension)
+			}
+			slot.Digits[kv[0]] = digit
+		}
+
+		for _, s := range e.slots {
+			if s.Dimension == slot.Dimension {
+				return FlavorEncoding{}, fmt.Errorf("dimension (%s) has more than one slot", slot.Dimension)
+			}
+		}
+		e.slots = append(e.slots, slot)
+	}
+
+	if len(e.slots) == 0 {
+		return FlavorEncoding{}, fmt.Errorf("no flavor slots in spec (%s)", spec)
+	}
+	return e, nil
+}
+
+// VariantVersionCode is the versionCode computed for a flavor combination.
+type VariantVersionCode struct {
+	Variant     string
+	VersionCode int
+}
+
+// VersionCodes encodes the flavors of every combination in front of the base versionCode.
+func (e FlavorEncoding) VersionCodes(combinations [][]ProductFlavor, base int) ([]VariantVersionCode, error) {
+	padded := fmt.Sprintf("%0*d", e.width, base)
+	if len(padded) > e.width {
+		return nil, fmt.Errorf("base versionCode (%d) overflows its %d digit slot", base, e.width)
+	}
+
+	var codes []VariantVersionCode
+	for _, combination := range combinations {
+		var names []string
+		flavorsByDimension := map[string]string{}
+		for _, f := range combination {
+			names = append(names, f.Name)
+			flavorsByDimension[f.Dimension] = f.Name
+		}
+		variant := variantName(names...)
+
+		digits := ""
+		for _, slot := range e.slots {
+			flavor, ok := flavorsByDimension[slot.Dimension]
+			if !ok {
+				return nil, fmt.Errorf("variant (%s) has no flavor in dimension (%s)", variant, slot.Dimension)
+			}
+			digit, ok := slot.Digits[flavor]
+			if !ok {
+				return nil, fmt.Errorf("no digit for flavor (%s) in the slot of dimension (%s)", flavor, slot.Dimension)
+			}
+			digits += strconv.Itoa(digit)
+		}
+
+		code, err := strconv.Atoi(digits + padded)
+		if err != nil {
+			return nil, fmt.Errorf("versionCode of variant (%s) is out of range: %s", variant, err)
+		}
+		codes = append(codes, VariantVersionCode{Variant: variant, VersionCode: code})
+	}
+	return codes, nil
+}
+
+// ValidateVariantVersionCodes checks that every variant versionCode is in range and unique.
+func ValidateVariantVersionCodes(codes []VariantVersionCode) []string {
+	var named []NamedVersionCode
+	for _, c := range codes {
+		named = append(named, NamedVersionCode{Name: c.Variant, VersionCode: c.VersionCode})
+	}
+	return ValidateVersionCodes(named)
+}
+
+func sliceContains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeFlavorVersionCodes computes the versionCode of every flavor combination of the updated build.gradle
+// by the flavor encoding spec. It returns the variant versionCodes as a JSON map.
+func encodeFlavorVersionCodes(cfg config, res UpdateResult) (string, error) {
+	base, err := strconv.Atoi(res.FinalVersionCode)
+	if err != nil {
+		return "", fmt.Errorf("final versionCode (%s) is not an integer", res.FinalVersionCode)
+	}
+
+	encoding, err := ParseFlavorEncoding(cfg.FlavorVersionCodeEncoding, cfg.FlavorVersionCodeWidth)
+	if err != nil {
+		return "", err
+	}
+
+	combinations, err := FlavorCombinations(ParseFlavorDimensions(res.NewContent), ParseProductFlavors(res.NewContent))
+	if err != nil {
+		return "", err
+	}
+	if len(combinations) == 0 {
+		return "", fmt.Errorf("no product flavors found in: %s", cfg.BuildGradlePth)
+	}
+
+	codes, err := encoding.VersionCodes(combinations, base)
+	if err != nil {
+		return "", err
+	}
+	if violations := ValidateVariantVersionCodes(codes); len(violations) > 0 {
+		return "", fmt.Errorf("invalid flavor versionCodes:\n- %s", strings.Join(violations, "\n- "))
+	}
+
+	codeMap := map[string]int{}
+	for _, c := range codes {
+		log.Printf("%s: %d", c.Variant, c.VersionCode)
+		codeMap[c.Variant] = c.VersionCode
+	}
+
+	out, err := json.Marshal(codeMap)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
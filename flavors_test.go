@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testFlavorsBuildGradle = `android {
+    flavorDimensions "store", "tier"
+    productFlavors {
+        play {
+            dimension "store"
+        }
+        amazon {
+            dimension "store"
+            applicationIdSuffix ".amazon"
+        }
+        free {
+            dimension "tier"
+        }
+        paid {
+            dimension "tier"
+            versionNameSuffix "-paid"
+        }
+    }
+}`
+
+func TestParseProductFlavors(t *testing.T) {
+	if got := ParseFlavorDimensions(testFlavorsBuildGradle); !reflect.DeepEqual(got, []string{"store", "tier"}) {
+		t.Errorf("ParseFlavorDimensions() = %v", got)
+	}
+
+	var got []string
+	for _, f := range ParseProductFlavors(testFlavorsBuildGradle) {
+		got = append(got, f.Name+":"+f.Dimension)
+	}
+	if want := []string{"play:store", "amazon:store", "free:tier", "paid:tier"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseProductFlavors() = %v, want %v", got, want)
+	}
+
+	kotlin := ParseProductFlavors(`android {
+    flavorDimensions += listOf("tier")
+    productFlavors {
+        create("free") { }
+        create("paid") { versionNameSuffix = "-paid" }
+    }
+}`)
+	if len(kotlin) != 2 || kotlin[1].Name != "paid" || kotlin[1].Dimension != "tier" {
+		t.Errorf("ParseProductFlavors() Kotlin DSL = %+v", kotlin)
+	}
+}
+
+func TestFlavorEncodingVersionCodes(t *testing.T) {
+	combinations, err := FlavorCombinations(ParseFlavorDimensions(testFlavorsBuildGradle), ParseProductFlavors(testFlavorsBuildGradle))
+	if err != nil {
+		t.Fatalf("FlavorCombinations() error = %s", err)
+	}
+
+	encoding, err := ParseFlavorEncoding("store:play=1,amazon=2|tier:free=0,paid=1", 4)
+	if err != nil {
+		t.Fatalf("ParseFlavorEncoding() error = %s", err)
+	}
+
+	got, err := encoding.VersionCodes(combinations, 42)
+	if err != nil {
+		t.Fatalf("VersionCodes() error = %s", err)
+	}
+	want := []VariantVersionCode{
+		{Variant: "playFree", VersionCode: 100042},
+		{Variant: "playPaid", VersionCode: 110042},
+		{Variant: "amazonFree", VersionCode: 200042},
+		{Variant: "amazonPaid", VersionCode: 210042},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("VersionCodes() = %+v, want %+v", got, want)
+	}
+	if violations := ValidateVariantVersionCodes(got); len(violations) > 0 {
+		t.Errorf("ValidateVariantVersionCodes() = %v", violations)
+	}
+
+	if _, err := encoding.VersionCodes(combinations, 12345); err == nil {
+		t.Errorf("VersionCodes() expected base slot overflow error")
+	}
+
+	colliding, err := ParseFlavorEncoding("store:play=1,amazon=1|tier:free=0,paid=1", 4)
+	if err != nil {
+		t.Fatalf("ParseFlavorEncoding() error = %s", err)
+	}
+	codes, err := colliding.VersionCodes(combinations, 42)
+	if err != nil {
+		t.Fatalf("VersionCodes() error = %s", err)
+	}
+	if violations := ValidateVariantVersionCodes(codes); len(violations) != 2 {
+		t.Errorf("ValidateVariantVersionCodes() = %v, want 2 collisions", violations)
+	}
+}
+
+func TestParseFlavorEncoding_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "store", "store:play", "store:play=10", "store:play=1|store:amazon=2"} {
+		if _, err := ParseFlavorEncoding(spec, 4); err == nil {
+			t.Errorf("ParseFlavorEncoding(%q) expected error", spec)
+		}
+	}
+}
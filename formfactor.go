@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// Form factors of Android modules.
+const (
+	formFactorPhone      = "phone"
+	formFactorWear       = "wear"
+	formFactorTV         = "tv"
+	formFactorAutomotive = "automotive"
+	formFactorInstant    = "instant"
+)
+
+var (
+	instantPluginRegexp   = regexp.MustCompile(`com\.android\.instantapp|com\.android\.feature`)
+	instantManifestRegexp = regexp.MustCompile(`dist:instant\s*=\s*"true"`)
+	// formFactorFeatures are the manifest uses-feature names identifying a form factor.
+	formFactorFeatures = []struct {
+		feature    string
+		formFactor string
+	}{
+		{"android.hardware.type.watch", formFactorWear},
+		{"android.software.leanback", formFactorTV},
+		{"android.hardware.type.automotive", formFactorAutomotive},
+	}
+)
+
+// DetectFormFactor returns the form factor of a module from its build.gradle plugins and AndroidManifest.xml features.
+func DetectFormFactor(buildGradle, manifest string) string {
+	if instantPluginRegexp.MatchString(buildGradle) || instantManifestRegexp.MatchString(manifest) {
+		return formFactorInstant
+	}
+	for _, f := range formFactorFeatures {
+		if regexp.MustCompile(`<uses-feature[^>]*android:name\s*=\s*"` + regexp.QuoteMeta(f.feature) + `"`).MatchString(manifest) {
+			return f.formFactor
+		}
+	}
+	return formFactorPhone
+}
+
+// FormFactorRule derives the versionCode of a form factor from the base versionCode.
+type FormFactorRule struct {
+	// Kind is prefix or offset.
+	Kind  string
+	Value int
+}
+
+// FormFactorScheme are the rules of the form factors, form factors without a rule use the base versionCode.
+type FormFactorScheme struct {
+	rules map[string]FormFactorRule
+	// width is the number of digits the base versionCode is padded to after a prefix.
+	width int
+}
+
+// ParseFormFactorScheme parses a pipe separated list of <form factor>:<prefix|offset>:<value> rules,
+// for example wear:prefix:2|instant:offset:-1.
+func ParseFormFactorScheme(scheme string, width int) (FormFactorScheme, error) {
+	s := FormFactorScheme{rules: map[string]FormFactorRule{}, width: width}
+	for _, item := range strings.Split(scheme, "|") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 {
+			return FormFactorScheme{}, fmt.Errorf("invalid form factor rule (%s), expected <form factor>:<prefix|offset>:<value>", item)
+		}
+		switch parts[0] {
+		case formFactorPhone, formFactorWear, formFactorTV, formFactorAutomotive, formFactorInstant:
+		default:
+			return FormFactorScheme{}, fmt.Errorf("unknown form factor (%s) in rule (%s)", parts[0], item)
+		}
+		if parts[1] != "prefix" && parts[1] != "offset" {
+			return FormFactorScheme{}, fmt.Errorf("unknown rule kind (%s) in rule (%s), expected prefix or offset", parts[1], item)
+		}
+		value, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return FormFactorScheme{}, fmt.Errorf("invalid value (%s) in rule (%s): %s", parts[2], item, err)
+		}
+		if parts[1] == "prefix" && value <= 0 {
+			return FormFactorScheme{}, fmt.Errorf("prefix (%d) in rule (%s) must be positive", value, item)
+		}
+		s.rules[parts[0]] = FormFactorRule{Kind: parts[1], Value: value}
+	}
+	return s, nil
+}
+
+// VersionCode returns the versionCode of the form factor from the base versionCode.
+func (s FormFactorScheme) VersionCode(formFactor string, base int) (int, error) {
+	rule, ok := s.rules[formFactor]
+	if !ok {
+		return base, nil
+	}
+
+	if rule.Kind == "offset" {
+		return base + rule.Value, nil
+	}
+
+	padded := fmt.Sprintf("%0*d", s.width, base)
+	if len(padded) > s.width {
+		return 0, fmt.Errorf("base versionCode (%d) does not fit in %d digits after the %s prefix (%d)", base, s.width, formFactor, rule.Value)
+	}
+	code, err := strconv.Atoi(strconv.Itoa(rule.Value) + padded)
+	if err != nil {
+		return 0, fmt.Errorf("prefixed versionCode of %s is out of range: %s", formFactor, err)
+	}
+	return code, nil
+}
+
+// ModuleVersionCode is the versionCode computed for a module.
+type ModuleVersionCode struct {
+	Module      string `json:"module"`
+	FormFactor  string `json:"form_factor"`
+	VersionCode int    `json:"version_code"`
+}
+
+// ValidateFormFactorOrdering checks the cross-module versionCode rules:
+// every versionCode is in range and unique, instant apps are lower and Wear OS apps are higher than every phone app.
+func ValidateFormFactorOrdering(codes []ModuleVersionCode) []string {
+	var named []NamedVersionCode
+	for _, c := range codes {
+		named = append(named, NamedVersionCode{Name: fmt.Sprintf("%s (%s)", c.Module, c.FormFactor), VersionCode: c.VersionCode})
+	}
+	violations := ValidateVersionCodes(named)
+
+	for _, phone := range codes {
+		if phone.FormFactor != formFactorPhone {
+			continue
+		}
+		for _, c := range codes {
+			switch {
+			case c.FormFactor == formFactorInstant && c.VersionCode >= phone.VersionCode:
+				violations = append(violations, fmt.Sprintf("instant app %s versionCode (%d) must be lower than installed app %s versionCode (%d)", c.Module, c.VersionCode, phone.Module, phone.VersionCode))
+			case c.FormFactor == formFactorWear && c.VersionCode <= phone.VersionCode:
+				violations = append(violations, fmt.Sprintf("Wear OS app %s versionCode (%d) must be higher than phone app %s versionCode (%d)", c.Module, c.VersionCode, phone.Module, phone.VersionCode))
+			}
+		}
+	}
+	return violations
+}
+
+// deriveFormFactorModules derives the versionCode of every module from the final versionCode by its form factor
+// and validates the cross-module ordering. It returns the module versionCodes and the updated module contents, nothing is written.
+// If the main build.gradle is one of the modules, its updated content is used instead of the file.
+func deriveFormFactorModules(cfg config, res UpdateResult) ([]ModuleVersionCode, map[string]string, error) {
+	base, err := strconv.Atoi(res.FinalVersionCode)
+	if err != nil {
+		return nil, nil, fmt.Errorf("final versionCode (%s) is not an integer", res.FinalVersionCode)
+	}
+
+	scheme, err := ParseFormFactorScheme(cfg.FormFactorScheme, cfg.FormFactorCodeWidth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mainPth, err := filepath.Abs(cfg.BuildGradlePth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var codes []ModuleVersionCode
+	contents := map[string]string{}
+	for _, pth := range cfg.FormFactorModules {
+		absPth, err := filepath.Abs(pth)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		content := res.NewContent
+		if absPth != mainPth {
+			if content, err = fileutil.ReadStringFromFile(pth); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		manifest := ""
+		manifestPth := filepath.Join(filepath.Dir(pth), "src", "main", "AndroidManifest.xml")
+		if exists, err := pathutil.IsPathExists(manifestPth); err != nil {
+			return nil, nil, err
+		} else if exists {
+			if manifest, err = fileutil.ReadStringFromFile(manifestPth); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		formFactor := DetectFormFactor(content, manifest)
+		code, err := scheme.VersionCode(formFactor, base)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Printf("%s: %s, versionCode: %d", pth, formFactor, code)
+		codes = append(codes, ModuleVersionCode{Module: pth, FormFactor: formFactor, VersionCode: code})
+
+		moduleRes, err := NewBuildGradleVersionUpdater(strings.NewReader(content)).UpdateVersion(code, 0, cfg.NewVersionName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if moduleRes.UpdatedVersionCodes == 0 {
+			log.Warnf("No versionCode found in: %s", pth)
+		}
+		contents[pth] = moduleRes.NewContent
+	}
+
+	if violations := ValidateFormFactorOrdering(codes); len(violations) > 0 {
+		return nil, nil, fmt.Errorf("invalid module versionCodes:\n- %s", strings.Join(violations, "\n- "))
+	}
+	return codes, contents, nil
+}
+
+// writeFormFactorModules writes the updated module contents. It returns the module versionCodes as JSON.
+func writeFormFactorModules(codes []ModuleVersionCode, contents map[string]string) (string, error) {
+	for _, c := range codes {
+		if err := fileutil.WriteStringToFile(c.Module, contents[c.Module]); err != nil {
+			return "", err
+		}
+	}
+
+	out, err := json.Marshal(codes)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectFormFactor(t *testing.T) {
+	tests := []struct {
+		name        string
+		buildGradle string
+		manifest    string
+		want        string
+	}{
+		{name: "Phone", buildGradle: `apply plugin: 'com.android.application'`, manifest: `<manifest/>`, want: formFactorPhone},
+		{
+			name:     "Wear OS",
+			manifest: `<manifest><uses-feature android:name="android.hardware.type.watch" /></manifest>`,
+			want:     formFactorWear,
+		},
+		{
+			name:     "TV",
+			manifest: `<manifest><uses-feature android:name="android.software.leanback" android:required="true" /></manifest>`,
+			want:     formFactorTV,
+		},
+		{
+			name:     "Automotive",
+			manifest: `<manifest><uses-feature android:name="android.hardware.type.automotive" android:required="true"/></manifest>`,
+			want:     formFactorAutomotive,
+		},
+		{name: "Instant app plugin", buildGradle: `plugins { id 'com.android.instantapp' }`, want: formFactorInstant},
+		{
+			name:     "Instant enabled module",
+			manifest: `<manifest><dist:module dist:instant="true" /></manifest>`,
+			want:     formFactorInstant,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormFactor(tt.buildGradle, tt.manifest); got != tt.want {
+				t.Errorf("DetectFormFactor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormFactorScheme_VersionCode(t *testing.T) {
+	scheme, err := ParseFormFactorScheme("wear:prefix:2|tv:prefix:3|instant:offset:-1", 8)
+	if err != nil {
+		t.Fatalf("ParseFormFactorScheme() error = %v", err)
+	}
+
+	tests := []struct {
+		formFactor string
+		base       int
+		want       int
+		wantErr    bool
+	}{
+		{formFactor: formFactorPhone, base: 1042, want: 1042},
+		{formFactor: formFactorWear, base: 1042, want: 200001042},
+		{formFactor: formFactorTV, base: 1042, want: 300001042},
+		{formFactor: formFactorInstant, base: 1042, want: 1041},
+		{formFactor: formFactorWear, base: 123456789, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.formFactor, func(t *testing.T) {
+			got, err := scheme.VersionCode(tt.formFactor, tt.base)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FormFactorScheme.VersionCode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FormFactorScheme.VersionCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"watch:prefix:2", "wear:suffix:2", "wear:prefix", "wear:prefix:0", "wear:offset:x"} {
+		if _, err := ParseFormFactorScheme(invalid, 8); err == nil {
+			t.Errorf("ParseFormFactorScheme(%s) expected error", invalid)
+		}
+	}
+}
+
+func TestValidateFormFactorOrdering(t *testing.T) {
+	tests := []struct {
+		name  string
+		codes []ModuleVersionCode
+		want  []string
+	}{
+		{
+			name: "Valid ordering",
+			codes: []ModuleVersionCode{
+				{Module: "app", FormFactor: formFactorPhone, VersionCode: 1042},
+				{Module: "wear", FormFactor: formFactorWear, VersionCode: 200001042},
+				{Module: "instant", FormFactor: formFactorInstant, VersionCode: 1041},
+			},
+		},
+		{
+			name: "Violations",
+			codes: []ModuleVersionCode{
+				{Module: "app", FormFactor: formFactorPhone, VersionCode: 1042},
+				{Module: "wear", FormFactor: formFactorWear, VersionCode: 1042},
+				{Module: "instant", FormFactor: formFactorInstant, VersionCode: 1043},
+			},
+			want: []string{
+				"app (phone), wear (wear) have the same versionCode (1042)",
+				"Wear OS app wear versionCode (1042) must be higher than phone app app versionCode (1042)",
+				"instant app instant versionCode (1043) must be lower than installed app app versionCode (1042)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateFormFactorOrdering(tt.codes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateFormFactorOrdering() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_deriveFormFactorModules_mainBuildGradle(t *testing.T) {
+	dir := t.TempDir()
+	appPth := filepath.Join(dir, "app", "build.gradle")
+	wearPth := filepath.Join(dir, "wear", "build.gradle")
+	for pth, content := range map[string]string{
+		appPth:  "android {\n    defaultConfig {\n        versionCode 1\n        buildConfigField \"String\", \"VERSION\", \"\\\"1.0\\\"\"\n    }\n}",
+		wearPth: "plugins {\n    id 'com.android.application'\n}\nandroid {\n    defaultConfig {\n        versionCode 1\n        minSdk 30\n    }\n}",
+	} {
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pth, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "wear", "src", "main"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "wear", "src", "main", "AndroidManifest.xml"), []byte(`<uses-feature android:name="android.hardware.type.watch" />`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the main build.gradle is already updated in memory, but not written yet
+	updated := "android {\n    defaultConfig {\n        versionCode 42\n        buildConfigField \"String\", \"VERSION\", \"\\\"2.0\\\"\"\n    }\n}"
+	cfg := config{
+		BuildGradlePth:      appPth,
+		FormFactorModules:   []string{filepath.Join(dir, "app", "..", "app", "build.gradle"), wearPth},
+		FormFactorScheme:    "wear:prefix:2",
+		FormFactorCodeWidth: 8,
+	}
+	codes, contents, err := deriveFormFactorModules(cfg, UpdateResult{NewContent: updated, FinalVersionCode: "42"})
+	if err != nil {
+		t.Fatalf("deriveFormFactorModules() error = %v", err)
+	}
+	if len(codes) != 2 || codes[0].VersionCode != 42 || codes[1].VersionCode != 200000042 {
+		t.Errorf("deriveFormFactorModules() codes = %+v", codes)
+	}
+	if got := contents[cfg.FormFactorModules[0]]; got != updated {
+		t.Errorf("deriveFormFactorModules() main build.gradle content = %q, want %q", got, updated)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-change-android-versioncode-and-versionname/conventionalcommit"
+)
+
+var (
+	// errShallowClone is returned when the commit history is incomplete.
+	errShallowClone = errors.New("repository is a shallow clone, commit history is incomplete")
+	// errNoMatchingTag is returned when no tag reachable from HEAD matches the pattern.
+	errNoMatchingTag = errors.New("no tag reachable from HEAD matches the pattern")
+)
+
+// GitRepository reads information from a local git repository, without any network access.
+type GitRepository struct {
+	dir string
+}
+
+// NewGitRepository constructs a new GitRepository for the given working directory.
+func NewGitRepository(dir string) GitRepository {
+	return GitRepository{dir: dir}
+}
+
+func (r GitRepository) git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := command.New("git", args...).SetDir(r.dir).SetStdout(&stdout).SetStderr(&stderr)
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %s: %s", cmd.PrintableCommandArgs(), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// IsShallow reports whether the repository is a shallow clone.
+func (r GitRepository) IsShallow() (bool, error) {
+	out, err := r.git("rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, err
+	}
+	return out == "true", nil
+}
+
+// CommitCount returns the number of commits reachable from HEAD, optionally scoped to the given sub-path.
+// It fails with errShallowClone on shallow clones, as the count would be silently too low.
+func (r GitRepository) CommitCount(path string) (int, error) {
+	shallow, err := r.IsShallow()
+	if err != nil {
+		return 0, err
+	}
+	if shallow {
+		return 0, errShallowClone
+	}
+
+	args := []string{"rev-list", "--count", "HEAD"}
+	if path != "" {
+		args = append(args, "--", path)
+	}
+
+	out, err := r.git(args...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(out))
+}
+
+// GitTag is a tag reachable from HEAD.
+type GitTag struct {
+	Name     string
+	Version  string
+	Distance int
+}
+
+// NearestTag returns the tag matching the pattern with the fewest commits between it and HEAD.
+// The version is the first capture group of the pattern, or the whole tag name if the pattern has no groups.
+func (r GitRepository) NearestTag(pattern *regexp.Regexp) (GitTag, error) {
+	out, err := r.git("for-each-ref", "--merged", "HEAD", "--sort=-creatordate", "--format=%(refname:short)", "refs/tags")
+	if err != nil {
+		return GitTag{}, err
+	}
+
+	var nearest *GitTag
+	for _, name := range strings.Split(out, "\n") {
+		match := pattern.FindStringSubmatch(name)
+		if name == "" || match == nil {
+			continue
+		}
+
+		version := match[0]
+		if len(match) > 1 {
+			version = match[1]
+		}
+
+		distance, err := r.git("rev-list", "--count", name+"..HEAD")
+		if err != nil {
+			return GitTag{}, err
+		}
+		tag := GitTag{Name: name, Version: version}
+		if tag.Distance, err = strconv.Atoi(distance); err != nil {
+			return GitTag{}, err
+		}
+
+		if nearest == nil || tag.Distance < nearest.Distance {
+			nearest = &tag
+		}
+	}
+
+	if nearest == nil {
+		return GitTag{}, errNoMatchingTag
+	}
+	return *nearest, nil
+}
+
+// ShortCommitHash returns the abbreviated hash of HEAD.
+func (r GitRepository) ShortCommitHash() (string, error) {
+	return r.git("rev-parse", "--short", "HEAD")
+}
+
+// DescribeVersionName returns the tag's version, suffixed with -dev.N+sha in the style of git describe
+// if HEAD is N commits past the tag.
+func (r GitRepository) DescribeVersionName(tag GitTag, devSuffix bool) (string, error) {
+	if !devSuffix || tag.Distance == 0 {
+		return tag.Version, nil
+	}
+
+	sha, err := r.ShortCommitHash()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-dev.%d+%s", tag.Version, tag.Distance, sha), nil
+}
+
+// CurrentBranch returns the name of the branch checked out at HEAD.
+func (r GitRepository) CurrentBranch() (string, error) {
+	branch, err := r.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if branch == "HEAD" {
+		return "", errors.New("HEAD is detached, no branch is checked out")
+	}
+	return branch, nil
+}
+
+// CommitHash returns the full hash of HEAD.
+func (r GitRepository) CommitHash() (string, error) {
+	return r.git("rev-parse", "HEAD")
+}
+
+// generateGitTagVersionName describes HEAD relative to the nearest tag matching the git tag pattern.
+func generateGitTagVersionName(cfg config) (string, error) {
+	pattern, err := regexp.Compile(cfg.GitTagPattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid git tag pattern (%s): %s", cfg.GitTagPattern, err)
+	}
+
+	repo := NewGitRepository(cfg.GitRepositoryPth)
+	tag, err := repo.NearestTag(pattern)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("nearest tag: %s (%d commits before HEAD)", tag.Name, tag.Distance)
+
+	return repo.DescribeVersionName(tag, cfg.GitTagDevSuffix)
+}
+
+// generateConventionalCommitsVersionName bumps the version of the nearest tag according to the commits since the tag.
+// It also returns the bump level and the commits which justify it.
+func generateConventionalCommitsVersionName(cfg config) (string, conventionalcommit.Bump, []conventionalcommit.Commit, error) {
+	pattern, err := regexp.Compile(cfg.GitTagPattern)
+	if err != nil {
+		return "", conventionalcommit.None, nil, fmt.Errorf("invalid git tag pattern (%s): %s", cfg.GitTagPattern, err)
+	}
+
+	tag, err := NewGitRepository(cfg.GitRepositoryPth).NearestTag(pattern)
+	if err != nil {
+		return "", conventionalcommit.None, nil, err
+	}
+
+	commits, err := conventionalcommit.Scan(cfg.GitRepositoryPth, tag.Name)
+	if err != nil {
+		return "", conventionalcommit.None, nil, err
+	}
+	log.Printf("%d commit(s) since tag: %s", len(commits), tag.Name)
+
+	bump, justifying := conventionalcommit.Classify(commits)
+	if bump == conventionalcommit.None {
+		log.Warnf("No feat, fix or breaking change commits since tag: %s, versionName remains %s", tag.Name, tag.Version)
+	}
+
+	versionName, err := conventionalcommit.Apply(tag.Version, bump)
+	if err != nil {
+		return "", conventionalcommit.None, nil, err
+	}
+	return versionName, bump, justifying, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/command"
+)
+
+// testGitRepository is a local git repository with a deterministic commit history.
+type testGitRepository struct {
+	t   *testing.T
+	dir string
+}
+
+func newTestGitRepository(t *testing.T) testGitRepository {
+	r := testGitRepository{t: t, dir: t.TempDir()}
+	r.git("init", "-q", "-b", "main")
+	r.git("config", "user.name", "tester")
+	r.git("config", "user.email", "tester@example.com")
+	return r
+}
+
+func (r testGitRepository) git(args ...string) string {
+	out, err := command.New("git", args...).SetDir(r.dir).AppendEnvs(
+		"GIT_AUTHOR_NAME=tester", "GIT_AUTHOR_EMAIL=tester@example.com", "GIT_AUTHOR_DATE=2021-03-04T10:00:00Z",
+		"GIT_COMMITTER_NAME=tester", "GIT_COMMITTER_EMAIL=tester@example.com", "GIT_COMMITTER_DATE=2021-03-04T10:00:00Z",
+	).RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v failed: %s: %s", args, err, out)
+	}
+	return out
+}
+
+// commit writes the given file and commits it with the given message.
+func (r testGitRepository) commit(pth, message string) {
+	abs := filepath.Join(r.dir, pth)
+	if err := os.MkdirAll(filepath.Dir(abs), 0755); err != nil {
+		r.t.Fatal(err)
+	}
+	f, err := os.OpenFile(abs, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	if _, err := f.WriteString(message + "\n"); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		r.t.Fatal(err)
+	}
+	r.git("add", pth)
+	r.git("commit", "-q", "-m", message)
+}
+
+func TestGitRepository_CommitCount(t *testing.T) {
+	repo := newTestGitRepository(t)
+	repo.commit("app/build.gradle", "initial")
+	repo.commit("lib/build.gradle", "add lib")
+	repo.commit("app/build.gradle", "update app")
+
+	shallow := filepath.Join(t.TempDir(), "shallow")
+	repo.git("clone", "-q", "--depth", "1", "file://"+repo.dir, shallow)
+
+	tests := []struct {
+		name    string
+		dir     string
+		path    string
+		want    int
+		wantErr error
+	}{
+		{name: "Counts all commits", dir: repo.dir, want: 3},
+		{name: "Counts commits touching the sub-path", dir: repo.dir, path: "app", want: 2},
+		{name: "Fails on shallow clone", dir: shallow, wantErr: errShallowClone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewGitRepository(tt.dir).CommitCount(tt.path)
+			if err != tt.wantErr {
+				t.Errorf("GitRepository.CommitCount() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GitRepository.CommitCount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitRepository_NearestTag(t *testing.T) {
+	repo := newTestGitRepository(t)
+	repo.commit("app/build.gradle", "initial")
+	repo.git("tag", "v1.0.0")
+	repo.commit("app/build.gradle", "fix")
+	repo.git("tag", "android/1.4.2")
+	repo.git("tag", "ios/2.0.0")
+	repo.commit("app/build.gradle", "feature")
+	repo.commit("app/build.gradle", "another feature")
+	sha := repo.git("rev-parse", "--short", "HEAD")
+
+	tests := []struct {
+		name      string
+		pattern   string
+		devSuffix bool
+		want      string
+		wantErr   bool
+	}{
+		{name: "Nearest matching tag", pattern: `^(?:v|android/)(\d+\.\d+\.\d+)$`, want: "1.4.2"},
+		{name: "Skips tags not matching", pattern: `^v(\d+\.\d+\.\d+)$`, want: "1.0.0"},
+		{name: "Whole tag without capture group", pattern: `^v\d+\.\d+\.\d+$`, want: "v1.0.0"},
+		{name: "Dev suffix past the tag", pattern: `^android/(.+)$`, devSuffix: true, want: "1.4.2-dev.2+" + sha},
+		{name: "No matching tag", pattern: `^release-(.+)$`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewGitRepository(repo.dir)
+			tag, err := r.NearestTag(regexp.MustCompile(tt.pattern))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GitRepository.NearestTag() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			got, err := r.DescribeVersionName(tag, tt.devSuffix)
+			if err != nil {
+				t.Fatalf("GitRepository.DescribeVersionName() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GitRepository.DescribeVersionName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitRepository_CurrentBranch(t *testing.T) {
+	repo := newTestGitRepository(t)
+	repo.commit("app/build.gradle", "initial")
+	repo.git("checkout", "-q", "-b", "release/1.5")
+
+	got, err := NewGitRepository(repo.dir).CurrentBranch()
+	if err != nil {
+		t.Fatalf("GitRepository.CurrentBranch() error = %v", err)
+	}
+	if got != "release/1.5" {
+		t.Errorf("GitRepository.CurrentBranch() = %v, want %v", got, "release/1.5")
+	}
+
+	repo.git("checkout", "-q", "--detach")
+	if _, err := NewGitRepository(repo.dir).CurrentBranch(); err == nil {
+		t.Errorf("GitRepository.CurrentBranch() expected error on detached HEAD")
+	}
+}
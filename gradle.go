@@ -0,0 +1,215 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GradleBlock is a named { ... } block of a Groovy or Kotlin DSL build script.
+type GradleBlock struct {
+	Name string
+	// Body is the content between the braces.
+	Body string
+	// Start and End are the offsets of the body in the parent content.
+	Start int
+	End   int
+}
+
+// gradleBlocks returns the top level blocks of the content, in order.
+// Strings and comments are skipped, so braces inside them do not count.
+func gradleBlocks(content string) []GradleBlock {
+	var blocks []GradleBlock
+	depth := 0
+	nameEnd, bodyStart := 0, 0
+	name := ""
+
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; {
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			i = skipUntil(content, i, "\n") - 1
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			i = skipUntil(content, i+2, "*/") - 1
+		case c == '"' || c == '\'':
+			i = skipString(content, i)
+		case c == '{':
+			if depth == 0 {
+				nameEnd = i
+				name = blockName(content[:nameEnd])
+				bodyStart = i + 1
+			}
+			depth++
+		case c == '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				blocks = append(blocks, GradleBlock{Name: name, Body: content[bodyStart:i], Start: bodyStart, End: i})
+			}
+		}
+	}
+	return blocks
+}
+
+// findGradleBlocks returns every block at the given path, for example findGradleBlocks(content, "android", "defaultConfig").
+// Offsets are relative to the content.
+func findGradleBlocks(content string, path ...string) []GradleBlock {
+	if len(path) == 0 {
+		return nil
+	}
+
+	var found []GradleBlock
+	for _, b := range gradleBlocks(content) {
+		if b.Name != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			found = append(found, b)
+			continue
+		}
+		for _, child := range findGradleBlocks(b.Body, path[1:]...) {
+			child.Start += b.Start
+			child.End += b.Start
+			found = append(found, child)
+		}
+	}
+	return found
+}
+
+// findGradleBlock returns the first block at the given path.
+func findGradleBlock(content string, path ...string) (GradleBlock, bool) {
+	blocks := findGradleBlocks(content, path...)
+	if len(blocks) == 0 {
+		return GradleBlock{}, false
+	}
+	return blocks[0], true
+}
+
+var blockNameRegexp = regexp.MustCompile(`([A-Za-z_][\w.]*)\s*(?:\(\s*"?([\w.-]*)"?\s*\))?\s*$`)
+
+// blockName returns the name the block is opened with, for example defaultConfig, or the argument of
+// create("paid") and getByName("release") calls, as used by the Kotlin DSL.
+func blockName(before string) string {
+	match := blockNameRegexp.FindStringSubmatch(before)
+	if match == nil {
+		return ""
+	}
+	if match[2] != "" {
+		return match[2]
+	}
+	return match[1]
+}
+
+func skipUntil(content string, from int, terminator string) int {
+	if i := strings.Index(content[from:], terminator); i >= 0 {
+		return from + i + len(terminator)
+	}
+	return len(content)
+}
+
+// skipString returns the offset of the closing quote of the string starting at from.
+func skipString(content string, from int) int {
+	quote := content[from]
+	if strings.HasPrefix(content[from:], `"""`) || strings.HasPrefix(content[from:], `'''`) {
+		return skipUntil(content, from+3, content[from:from+3]) - 1
+	}
+	for i := from + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		case '\n':
+			return i
+		}
+	}
+	return len(content)
+}
+
+// GradleProperty is a property assignment of a block, like versionCode 1 or versionNameSuffix = "-paid".
+type GradleProperty struct {
+	Name string
+	// Value is the raw assigned expression, Start and End are its offsets in the block body.
+	Value string
+	Start int
+	End   int
+}
+
+// findGradleProperty returns the last assignment of the property directly in the block body,
+// assignments in nested blocks are skipped.
+func findGradleProperty(body, name string) (GradleProperty, bool) {
+	// blank the nested blocks, keeping the offsets
+	own := []byte(body)
+	for _, b := range gradleBlocks(body) {
+		for i := b.Start; i < b.End; i++ {
+			if own[i] != '\n' {
+				own[i] = ' '
+			}
+		}
+	}
+
+	re := regexp.MustCompile(`(?m)^[ \t]*` + regexp.QuoteMeta(name) + `([ \t]*=[ \t]*|[ \t]*\([ \t]*|[ \t]+)(.*?)[ \t]*(?:;[ \t]*)?(?://.*)?$`)
+	matches := re.FindAllSubmatchIndex(own, -1)
+	if len(matches) == 0 {
+		return GradleProperty{}, false
+	}
+
+	m := matches[len(matches)-1]
+	start, end := m[4], m[5]
+	if strings.Contains(body[m[2]:m[3]], "(") && strings.HasSuffix(body[start:end], ")") {
+		end--
+	}
+	return GradleProperty{Name: name, Value: strings.TrimSpace(body[start:end]), Start: start, End: end}, true
+}
+
+var (
+	gradleIntegerLiteralRegexp = regexp.MustCompile(`^\d+$`)
+	gradleStringLiteralRegexp  = regexp.MustCompile(`^(?:"([^"\\$]*)"|'([^'\\]*)')$`)
+)
+
+// gradleLiteral returns the value of an integer or string literal without interpolation or escapes,
+// false if the expression can not be evaluated statically.
+func gradleLiteral(expression string) (string, bool) {
+	if gradleIntegerLiteralRegexp.MatchString(expression) {
+		return expression, true
+	}
+	if match := gradleStringLiteralRegexp.FindStringSubmatch(expression); match != nil {
+		return match[1] + match[2], true
+	}
+	return "", false
+}
+
+// setGradleProperty assigns the value expression to the property directly in the block of the content:
+// an existing assignment is replaced, otherwise a new one is added as the first line of the block.
+// Kotlin DSL scripts use the name = value syntax.
+func setGradleProperty(content string, block GradleBlock, name, value string, kotlin bool) string {
+	if p, ok := findGradleProperty(block.Body, name); ok {
+		return content[:block.Start+p.Start] + value + content[block.Start+p.End:]
+	}
+
+	statement := name + " " + value
+	if kotlin {
+		statement = name + " = " + value
+	}
+
+	lineStart := strings.LastIndex(content[:block.Start], "\n") + 1
+	line := content[lineStart:block.Start]
+	parentIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	indent := parentIndent + "    "
+	if strings.Contains(parentIndent, "\t") {
+		indent = parentIndent + "\t"
+	}
+
+	if strings.TrimSpace(block.Body) == "" {
+		return content[:block.Start] + "\n" + indent + statement + "\n" + parentIndent + content[block.End:]
+	}
+
+	insert := "\n" + indent + statement
+	rest := content[block.Start:]
+	if firstLine := strings.SplitN(rest, "\n", 2)[0]; strings.TrimSpace(firstLine) != "" {
+		// the block starts on the same line, like free { versionNameSuffix '-free' }
+		insert += "\n" + indent
+		rest = strings.TrimLeft(rest, " \t")
+	}
+	return content[:block.Start] + insert + rest
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_findGradleBlocks(t *testing.T) {
+	content := `android {
+    // a comment with a brace {
+    defaultConfig {
+        versionName "1.0 }"
+    }
+    /* block comment } */
+    productFlavors {
+        free { versionNameSuffix '-free' }
+        create("paid") {
+            versionNameSuffix = "-paid"
+        }
+    }
+}`
+
+	tests := []struct {
+		name string
+		path []string
+		want []string
+	}{
+		{name: "Nested block", path: []string{"android", "defaultConfig"}, want: []string{"\n        versionName \"1.0 }\"\n    "}},
+		{name: "Groovy flavor", path: []string{"android", "productFlavors", "free"}, want: []string{" versionNameSuffix '-free' "}},
+		{name: "Kotlin DSL flavor", path: []string{"android", "productFlavors", "paid"}, want: []string{"\n            versionNameSuffix = \"-paid\"\n        "}},
+		{name: "Missing block", path: []string{"android", "buildTypes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, b := range findGradleBlocks(content, tt.path...) {
+				if content[b.Start:b.End] != b.Body {
+					t.Errorf("block offsets do not match its body: %q", content[b.Start:b.End])
+				}
+				got = append(got, b.Body)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findGradleBlocks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_gradleBlocks_blockComments(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []GradleBlock
+	}{
+		{
+			name:    "Comment followed by a closing brace",
+			content: "qa { /* internal */}\nrelease { }",
+			want: []GradleBlock{
+				{Name: "qa", Body: " /* internal */", Start: 4, End: 19},
+				{Name: "release", Body: " ", Start: 30, End: 31},
+			},
+		},
+		{
+			name:    "Comment followed by an opening brace",
+			content: "qa {/* nested */{ } versionCode 2 }",
+			want:    []GradleBlock{{Name: "qa", Body: "/* nested */{ } versionCode 2 ", Start: 4, End: 34}},
+		},
+		{
+			name:    "Comment followed by a string",
+			content: `qa { /* suffix */"}" }`,
+			want:    []GradleBlock{{Name: "qa", Body: ` /* suffix */"}" `, Start: 4, End: 21}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gradleBlocks(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("gradleBlocks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_findGradleProperty(t *testing.T) {
+	body := `
+        versionCode 12 // comment
+        release {
+            versionNameSuffix "-nested"
+        }
+        versionNameSuffix = "-qa.${build}"
+        applicationIdSuffix(".qa")
+`
+
+	tests := []struct {
+		name      string
+		property  string
+		wantValue string
+		wantOK    bool
+	}{
+		{name: "Groovy assignment with comment", property: "versionCode", wantValue: "12", wantOK: true},
+		{name: "Nested blocks are skipped", property: "versionNameSuffix", wantValue: `"-qa.${build}"`, wantOK: true},
+		{name: "Method call", property: "applicationIdSuffix", wantValue: `".qa"`, wantOK: true},
+		{name: "Missing property", property: "versionName"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findGradleProperty(body, tt.property)
+			if ok != tt.wantOK || got.Value != tt.wantValue {
+				t.Errorf("findGradleProperty() = %+v, %v, want %s, %v", got, ok, tt.wantValue, tt.wantOK)
+			}
+			if ok && body[got.Start:got.End] != got.Value {
+				t.Errorf("property offsets do not match its value: %q", body[got.Start:got.End])
+			}
+		})
+	}
+}
+
+func Test_gradleLiteral(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+		wantOK     bool
+	}{
+		{expression: "42", want: "42", wantOK: true},
+		{expression: `"1.2.0"`, want: "1.2.0", wantOK: true},
+		{expression: `'-paid'`, want: "-paid", wantOK: true},
+		{expression: `"1.2.${build}"`},
+		{expression: "rootProject.ext.versionCode"},
+	}
+	for _, tt := range tests {
+		if got, ok := gradleLiteral(tt.expression); got != tt.want || ok != tt.wantOK {
+			t.Errorf("gradleLiteral(%s) = %s, %v, want %s, %v", tt.expression, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
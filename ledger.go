@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// LedgerEntry is a version recorded in the version ledger.
+type LedgerEntry struct {
+	App         string    `json:"app"`
+	Track       string    `json:"track"`
+	VersionCode int       `json:"version_code"`
+	VersionName string    `json:"version_name"`
+	Commit      string    `json:"commit"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// VersionLedger stores the history of the versions set by the step.
+type VersionLedger interface {
+	Entries() ([]LedgerEntry, error)
+	Append(entry LedgerEntry) error
+}
+
+// NewVersionLedger constructs the VersionLedger of the given type (json, csv or git_notes).
+// The location is the ledger file path, or the notes ref for git notes.
+func NewVersionLedger(ledgerType, location string, repo GitRepository) (VersionLedger, error) {
+	switch ledgerType {
+	case "json":
+		return JSONLedger{pth: location}, nil
+	case "csv":
+		return CSVLedger{pth: location}, nil
+	case "git_notes":
+		return GitNotesLedger{repo: repo, ref: location}, nil
+	default:
+		return nil, fmt.Errorf("unknown ledger type (%s)", ledgerType)
+	}
+}
+
+// CheckMonotonic returns an error if the versionCode is not strictly greater than
+// the greatest one recorded for the app and track.
+func CheckMonotonic(entries []LedgerEntry, app, track string, versionCode int) error {
+	var last *LedgerEntry
+	for i, e := range entries {
+		if e.App == app && e.Track == track && (last == nil || e.VersionCode > last.VersionCode) {
+			last = &entries[i]
+		}
+	}
+	if last != nil && versionCode <= last.VersionCode {
+		return fmt.Errorf("versionCode (%d) is not greater than the last recorded versionCode (%d, versionName: %s, commit: %s, at: %s) of app (%s) on track (%s)",
+			versionCode, last.VersionCode, last.VersionName, last.Commit, last.Timestamp.Format(time.RFC3339), app, track)
+	}
+	return nil
+}
+
+func readLedgerFile(pth string) ([]byte, error) {
+	if exists, err := pathutil.IsPathExists(pth); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, nil
+	}
+	return fileutil.ReadBytesFromFile(pth)
+}
+
+// JSONLedger stores the entries as a JSON array in a file.
+type JSONLedger struct {
+	pth string
+}
+
+// Entries returns the recorded entries, a missing file is an empty ledger.
+func (l JSONLedger) Entries() ([]LedgerEntry, error) {
+	content, err := readLedgerFile(l.pth)
+	if err != nil || len(strings.TrimSpace(string(content))) == 0 {
+		return nil, err
+	}
+
+	var entries []LedgerEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse ledger (%s): %s", l.pth, err)
+	}
+	return entries, nil
+}
+
+// Append records a new entry.
+func (l JSONLedger) Append(entry LedgerEntry) error {
+	entries, err := l.Entries()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(append(entries, entry), "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteBytesToFile(l.pth, append(content, '\n'))
+}
+
+var csvLedgerHeader = []string{"app", "track", "version_code", "version_name", "commit", "timestamp"}
+
+// CSVLedger stores the entries as CSV rows in a file.
+type CSVLedger struct {
+	pth string
+}
+
+// Entries returns the recorded entries, a missing file is an empty ledger.
+func (l CSVLedger) Entries() ([]LedgerEntry, error) {
+	content, err := readLedgerFile(l.pth)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ledger (%s): %s", l.pth, err)
+	}
+
+	var entries []LedgerEntry
+	for i, record := range records {
+		if i == 0 && record[0] == csvLedgerHeader[0] {
+			continue
+		}
+		if len(record) != len(csvLedgerHeader) {
+			return nil, fmt.Errorf("invalid ledger (%s) row %d: expected %d fields, got %d", l.pth, i+1, len(csvLedgerHeader), len(record))
+		}
+
+		entry := LedgerEntry{App: record[0], Track: record[1], VersionName: record[3], Commit: record[4]}
+		if entry.VersionCode, err = strconv.Atoi(record[2]); err != nil {
+			return nil, fmt.Errorf("invalid ledger (%s) row %d: %s", l.pth, i+1, err)
+		}
+		if entry.Timestamp, err = time.Parse(time.RFC3339, record[5]); err != nil {
+			return nil, fmt.Errorf("invalid ledger (%s) row %d: %s", l.pth, i+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Append records a new entry.
+func (l CSVLedger) Append(entry LedgerEntry) error {
+	exists, err := pathutil.IsPathExists(l.pth)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if !exists {
+		if err := w.Write(csvLedgerHeader); err != nil {
+			return err
+		}
+	}
+	if err := w.Write([]string{
+		entry.App, entry.Track, strconv.Itoa(entry.VersionCode), entry.VersionName, entry.Commit, entry.Timestamp.Format(time.RFC3339),
+	}); err != nil {
+		return err
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return fileutil.AppendStringToFile(l.pth, b.String())
+}
+
+// GitNotesLedger stores the entries as JSON lines in git notes attached to the recorded commits.
+// The notes ref has to be fetched before and pushed after the step, as the step does not access the network.
+type GitNotesLedger struct {
+	repo GitRepository
+	ref  string
+}
+
+// Entries returns the entries of every note under the ref.
+func (l GitNotesLedger) Entries() ([]LedgerEntry, error) {
+	out, err := l.repo.git("notes", "--ref", l.ref, "list")
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []LedgerEntry
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		note, err := l.repo.git("notes", "--ref", l.ref, "show", fields[1])
+		if err != nil {
+			return nil, err
+		}
+		for _, noteLine := range strings.Split(note, "\n") {
+			if strings.TrimSpace(noteLine) == "" {
+				continue
+			}
+			var entry LedgerEntry
+			if err := json.Unmarshal([]byte(noteLine), &entry); err != nil {
+				return nil, fmt.Errorf("failed to parse note of commit (%s): %s", fields[1], err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Append records a new entry in the note of the entry's commit.
+func (l GitNotesLedger) Append(entry LedgerEntry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = l.repo.git("notes", "--ref", l.ref, "append", "-m", string(content), entry.Commit)
+	return err
+}
+
+// checkVersionLedger fails if the final versionCode is not greater than the last one recorded in the ledger.
+// It returns the entry to record once every file is written and every output is exported.
+func checkVersionLedger(cfg config, res UpdateResult) (VersionLedger, LedgerEntry, error) {
+	versionCode, err := strconv.Atoi(res.FinalVersionCode)
+	if err != nil {
+		return nil, LedgerEntry{}, fmt.Errorf("versionCode (%s) is not an integer", res.FinalVersionCode)
+	}
+
+	repo := NewGitRepository(cfg.GitRepositoryPth)
+	ledger, err := NewVersionLedger(cfg.VersionLedgerType, cfg.VersionLedgerPth, repo)
+	if err != nil {
+		return nil, LedgerEntry{}, err
+	}
+
+	entries, err := ledger.Entries()
+	if err != nil {
+		return nil, LedgerEntry{}, err
+	}
+	if err := CheckMonotonic(entries, cfg.VersionLedgerApp, cfg.VersionLedgerTrack, versionCode); err != nil {
+		return nil, LedgerEntry{}, err
+	}
+
+	commit, err := repo.CommitHash()
+	if err != nil {
+		if cfg.VersionLedgerType == "git_notes" {
+			return nil, LedgerEntry{}, err
+		}
+		log.Warnf("Failed to read the current commit: %s", err)
+	}
+
+	return ledger, LedgerEntry{
+		App:         cfg.VersionLedgerApp,
+		Track:       cfg.VersionLedgerTrack,
+		VersionCode: versionCode,
+		VersionName: removeQuotationMarks(res.FinalVersionName),
+		Commit:      commit,
+		Timestamp:   time.Now().UTC().Truncate(time.Second),
+	}, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVersionLedger(t *testing.T) {
+	repo := newTestGitRepository(t)
+	repo.commit("app/build.gradle", "initial")
+	commit := repo.git("rev-parse", "HEAD")
+
+	entries := []LedgerEntry{
+		{App: "com.example", Track: "production", VersionCode: 41, VersionName: "1.4.0", Commit: commit, Timestamp: time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC)},
+		{App: "com.example", Track: "production", VersionCode: 42, VersionName: "1.5.0", Commit: commit, Timestamp: time.Date(2021, time.March, 4, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, ledgerType := range []string{"json", "csv", "git_notes"} {
+		t.Run(ledgerType, func(t *testing.T) {
+			location := filepath.Join(t.TempDir(), "versions."+ledgerType)
+			if ledgerType == "git_notes" {
+				location = "refs/notes/versions-test"
+			}
+
+			ledger, err := NewVersionLedger(ledgerType, location, NewGitRepository(repo.dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ledger.Entries()
+			if err != nil || len(got) != 0 {
+				t.Fatalf("Entries() of a new ledger = %v, %v, want empty", got, err)
+			}
+
+			for _, e := range entries {
+				if err := ledger.Append(e); err != nil {
+					t.Fatalf("Append() error = %v", err)
+				}
+			}
+
+			got, err = ledger.Entries()
+			if err != nil {
+				t.Fatalf("Entries() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, entries) {
+				t.Errorf("Entries() = %v, want %v", got, entries)
+			}
+		})
+	}
+}
+
+func TestCheckMonotonic(t *testing.T) {
+	entries := []LedgerEntry{
+		{App: "com.example", Track: "production", VersionCode: 42},
+		{App: "com.example", Track: "production", VersionCode: 40},
+		{App: "com.example", Track: "beta", VersionCode: 50},
+		{App: "com.example.other", Track: "production", VersionCode: 100},
+	}
+
+	tests := []struct {
+		name        string
+		track       string
+		versionCode int
+		wantErr     bool
+	}{
+		{name: "Greater than the last one", track: "production", versionCode: 43},
+		{name: "Equal to the last one", track: "production", versionCode: 42, wantErr: true},
+		{name: "Lower than the last one", track: "production", versionCode: 41, wantErr: true},
+		{name: "Tracks are separate", track: "beta", versionCode: 49, wantErr: true},
+		{name: "New track", track: "internal", versionCode: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckMonotonic(entries, "com.example", tt.track, tt.versionCode); (err != nil) != tt.wantErr {
+				t.Errorf("CheckMonotonic() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
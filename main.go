@@ -13,6 +13,7 @@ import (
 	"github.com/bitrise-io/go-utils/command"
 	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-change-android-versioncode-and-versionname/conventionalcommit"
 )
 
 const (
@@ -25,8 +26,80 @@ const (
 type config struct {
 	BuildGradlePth    string `env:"build_gradle_path,file"`
 	NewVersionName    string `env:"new_version_name"`
-	NewVersionCode    int    `env:"new_version_code,range]0..2100000000]"`
+	NewVersionCode    string `env:"new_version_code"`
 	VersionCodeOffset int    `env:"version_code_offset"`
+
+	VersionCodeSource   string `env:"version_code_source,opt[input,date,git_commit_count,allocator,semver]"`
+	VersionCodeDateFmt  string `env:"version_code_date_format"`
+	VersionCodeTimeZone string `env:"version_code_time_zone"`
+
+	GitRepositoryPth        string `env:"git_repository_path"`
+	GitCommitCountPth       string `env:"git_commit_count_path"`
+	GitShallowCloneFallback string `env:"git_shallow_clone_fallback,opt[fail,input]"`
+
+	VersionNameSource string `env:"version_name_source,opt[input,git_tag,branch,conventional_commits]"`
+	GitTagPattern     string `env:"git_tag_pattern"`
+	GitTagDevSuffix   bool   `env:"git_tag_dev_suffix,opt[yes,no]"`
+
+	Branch                    string `env:"branch"`
+	BranchPattern             string `env:"branch_pattern"`
+	BranchVersionNameTemplate string `env:"branch_version_name_template"`
+	BuildNumber               string `env:"build_number"`
+
+	GenerateChangelog bool   `env:"generate_changelog,opt[yes,no]"`
+	ChangelogPth      string `env:"changelog_path"`
+	DeployDir         string `env:"deploy_dir"`
+
+	PlayChangelogLocales     []string `env:"play_changelog_locales"`
+	PlayChangelogMetadataDir string   `env:"play_changelog_metadata_dir"`
+	PlayChangelogTemplate    string   `env:"play_changelog_template"`
+	PlayChangelogSourcePth   string   `env:"play_changelog_source_path"`
+	PlayChangelogOverwrite   bool     `env:"play_changelog_overwrite,opt[yes,no]"`
+
+	VersionLedgerType  string `env:"version_ledger_type,opt[none,json,csv,git_notes]"`
+	VersionLedgerPth   string `env:"version_ledger_path"`
+	VersionLedgerApp   string `env:"version_ledger_app"`
+	VersionLedgerTrack string `env:"version_ledger_track"`
+
+	PlayVersionCodeMode       string `env:"play_version_code_mode,opt[none,next,enforce]"`
+	PlayAPIBaseURL            string `env:"play_api_base_url"`
+	PlayPackageName           string `env:"play_package_name"`
+	PlayServiceAccountJSONPth string `env:"play_service_account_json_path"`
+	PlayTracksFixturePth      string `env:"play_tracks_fixture_path"`
+
+	AllocatorURL     string `env:"allocator_url"`
+	AllocatorAppID   string `env:"allocator_app_id"`
+	AllocatorBuildID string `env:"allocator_build_id"`
+
+	StoreProfiles []string `env:"store_profiles"`
+
+	VersionPolicyPth string `env:"version_policy_path"`
+
+	FormFactorModules   []string `env:"form_factor_modules"`
+	FormFactorScheme    string   `env:"form_factor_scheme"`
+	FormFactorCodeWidth int      `env:"form_factor_code_width,range]0..9]"`
+
+	SplitVersionCodes          string `env:"split_version_codes,opt[none,abi_multiplier,min_sdk_density_abi]"`
+	SplitVersionCodeMultiplier int    `env:"split_version_code_multiplier"`
+
+	FlavorVersionCodeEncoding string `env:"flavor_version_code_encoding"`
+	FlavorVersionCodeWidth    int    `env:"flavor_version_code_width,range]0..9]"`
+
+	ExportVariantVersions bool `env:"export_variant_versions,opt[yes,no]"`
+
+	VersionOverrideCheck    string `env:"version_override_check,opt[warn,strict]"`
+	RewriteVersionOverrides bool   `env:"rewrite_version_overrides,opt[yes,no]"`
+
+	VersionNameSuffixes   []string `env:"version_name_suffixes"`
+	ApplicationIDSuffixes []string `env:"application_id_suffixes"`
+
+	BuildConfigFields []string `env:"build_config_fields"`
+	ResValues         []string `env:"res_values"`
+
+	StringResources          []string `env:"string_resources"`
+	StringResourceSourceSets []string `env:"string_resource_source_sets"`
+
+	TemplateFiles []string `env:"template_files"`
 }
 
 type updateFn func(line string, lineNum int, matches []string) string
@@ -117,11 +190,8 @@ func (u BuildGradleVersionUpdater) UpdateVersion(newVersionCode, versionCodeOffs
 			updatedLine := ""
 
 			if newVersionName != "" {
-				quotedNewVersionName := newVersionName
-				if !(strings.HasPrefix(quotedNewVersionName, `"`) && strings.HasSuffix(quotedNewVersionName, `"`)) {
-					quotedNewVersionName = strings.TrimPrefix(quotedNewVersionName, `"`)
-					quotedNewVersionName = strings.TrimSuffix(quotedNewVersionName, `"`)
-					quotedNewVersionName = `"` + quotedNewVersionName + `"`
+				quotedNewVersionName, added := quoteGradleString(newVersionName)
+				if added {
 					log.Warnf(`Leading and/or trailing " character missing from new_version_name, adding quotation char: %s -> %s`, newVersionName, quotedNewVersionName)
 				}
 
@@ -148,44 +218,463 @@ func main() {
 	stepconf.Print(cfg)
 	fmt.Println()
 
-	if cfg.NewVersionName == "" && cfg.NewVersionCode == 0 {
+	if cfg.NewVersionName == "" && cfg.VersionNameSource == "input" && cfg.NewVersionCode == "" && cfg.VersionCodeSource == "input" && cfg.PlayVersionCodeMode != "next" {
 		failf("Neither NewVersionCode nor NewVersionName are provided, however one of them is required.")
 	}
 
+	content, err := fileutil.ReadStringFromFile(cfg.BuildGradlePth)
+	if err != nil {
+		failf("Failed to read build.gradle file, error: %s", err)
+	}
+
+	outputs := map[string]string{}
+
+	current, err := NewBuildGradleVersionUpdater(strings.NewReader(content)).UpdateVersion(0, 0, "")
+	if err != nil {
+		failf("Failed to read current versions: %s", err)
+	}
+
+	//
+	// read versions from source files
+	if IsVersionSourceReference(cfg.NewVersionName) {
+		fmt.Println()
+		log.Infof("Reading versionName from: %s", cfg.NewVersionName)
+
+		cfg.NewVersionName, err = ReadVersionSource(cfg.NewVersionName)
+		if err != nil {
+			failf("Failed to read versionName: %s", err)
+		}
+		if strings.ContainsAny(cfg.NewVersionName, "\"\\$") {
+			failf("versionName (%s) contains quote, backslash or $ characters", cfg.NewVersionName)
+		}
+		log.Printf("versionName: %s", cfg.NewVersionName)
+	}
+
+	if IsVersionSourceReference(cfg.NewVersionCode) {
+		fmt.Println()
+		log.Infof("Reading versionCode from: %s", cfg.NewVersionCode)
+
+		cfg.NewVersionCode, err = ReadVersionSource(cfg.NewVersionCode)
+		if err != nil {
+			failf("Failed to read versionCode: %s", err)
+		}
+		if _, err := strconv.Atoi(cfg.NewVersionCode); err != nil {
+			failf("versionCode (%s) is not an integer", cfg.NewVersionCode)
+		}
+		log.Printf("versionCode: %s", cfg.NewVersionCode)
+	}
+
+	newVersionCode := 0
+	if cfg.NewVersionCode != "" {
+		newVersionCode, err = evaluateNewVersionCode(cfg, current.FinalVersionCode)
+		if err != nil {
+			failf("Failed to evaluate new_version_code: %s", err)
+		}
+	}
+
+	//
+	// generate versionCode
+	if cfg.VersionCodeSource == "date" {
+		fmt.Println()
+		log.Infof("Generating versionCode from date format: %s", cfg.VersionCodeDateFmt)
+
+		newVersionCode, err = generateDateVersionCode(cfg, current.FinalVersionCode)
+		if err != nil {
+			failf("Failed to generate versionCode: %s", err)
+		}
+		log.Printf("generated versionCode: %d", newVersionCode)
+	}
+
+	if cfg.VersionCodeSource == "git_commit_count" {
+		fmt.Println()
+		log.Infof("Generating versionCode from git commit count in: %s", cfg.GitRepositoryPth)
+
+		count, err := NewGitRepository(cfg.GitRepositoryPth).CommitCount(cfg.GitCommitCountPth)
+		switch {
+		case err == errShallowClone && cfg.GitShallowCloneFallback == "input":
+			log.Warnf("%s, falling back to new_version_code: %d", err, newVersionCode)
+		case err != nil:
+			failf("Failed to count git commits: %s", err)
+		default:
+			newVersionCode = count
+			log.Printf("commit count: %d", count)
+		}
+	}
+
+	if cfg.VersionCodeSource == "allocator" {
+		fmt.Println()
+		log.Infof("Requesting versionCode from the allocation service: %s", cfg.AllocatorURL)
+
+		newVersionCode, err = leaseAllocatorVersionCode(cfg, current.FinalVersionCode)
+		if err != nil {
+			failf("Failed to lease versionCode: %s", err)
+		}
+		log.Printf("leased versionCode: %d", newVersionCode)
+	}
+
+	playMaxVersionCode := 0
+	if cfg.PlayVersionCodeMode != "none" {
+		fmt.Println()
+		log.Infof("Looking up the greatest versionCode released to Google Play")
+
+		playMaxVersionCode, err = lookupPlayMaxVersionCode(cfg)
+		if err != nil {
+			failf("Failed to look up Google Play versionCodes: %s", err)
+		}
+		log.Printf("greatest released versionCode: %d", playMaxVersionCode)
+
+		if cfg.PlayVersionCodeMode == "next" {
+			newVersionCode, err = nextPlayVersionCode(playMaxVersionCode, cfg.VersionCodeOffset)
+			if err != nil {
+				failf("Failed to generate versionCode: %s", err)
+			}
+			log.Printf("generated versionCode: %d", newVersionCode)
+		}
+	}
+
+	//
+	// generate versionName
+	if cfg.VersionNameSource == "git_tag" {
+		fmt.Println()
+		log.Infof("Generating versionName from the nearest git tag matching: %s", cfg.GitTagPattern)
+
+		cfg.NewVersionName, err = generateGitTagVersionName(cfg)
+		if err != nil {
+			failf("Failed to generate versionName: %s", err)
+		}
+		log.Printf("generated versionName: %s", cfg.NewVersionName)
+	}
+
+	if cfg.VersionNameSource == "branch" {
+		fmt.Println()
+		log.Infof("Generating versionName from branch name matching: %s", cfg.BranchPattern)
+
+		cfg.NewVersionName, err = generateBranchVersionName(cfg)
+		if err != nil {
+			failf("Failed to generate versionName: %s", err)
+		}
+		log.Printf("generated versionName: %s", cfg.NewVersionName)
+	}
+
+	if cfg.VersionNameSource == "conventional_commits" {
+		fmt.Println()
+		log.Infof("Generating versionName from conventional commits since the nearest git tag matching: %s", cfg.GitTagPattern)
+
+		var bump conventionalcommit.Bump
+		var commits []conventionalcommit.Commit
+		cfg.NewVersionName, bump, commits, err = generateConventionalCommitsVersionName(cfg)
+		if err != nil {
+			failf("Failed to generate versionName: %s", err)
+		}
+		log.Printf("bump: %s, generated versionName: %s", bump, cfg.NewVersionName)
+
+		var lines []string
+		for _, c := range commits {
+			lines = append(lines, c.Hash+" "+c.Subject)
+			log.Printf("- %s", c.Subject)
+		}
+		outputs["ANDROID_VERSION_BUMP"] = bump.String()
+		outputs["ANDROID_VERSION_BUMP_COMMITS"] = strings.Join(lines, "\n")
+	}
+
+	//
+	// expand versionName template
+	if HasVersionNamePlaceholders(cfg.NewVersionName) {
+		fmt.Println()
+		log.Infof("Expanding versionName template: %s", cfg.NewVersionName)
+
+		cfg.NewVersionName, err = ExpandVersionNameTemplate(cfg.NewVersionName, versionNameTemplateResolver(cfg, current))
+		if err != nil {
+			failf("Failed to expand versionName template: %s", err)
+		}
+		log.Printf("expanded versionName: %s", cfg.NewVersionName)
+	}
+
+	if cfg.VersionCodeSource == "semver" {
+		fmt.Println()
+		log.Infof("Generating versionCode from the semantic versionName")
+
+		newVersionCode, err = generateSemverVersionCode(cfg, current.FinalVersionName)
+		if err != nil {
+			failf("Failed to generate versionCode: %s", err)
+		}
+		log.Printf("generated versionCode: %d", newVersionCode)
+	}
+
 	//
 	// find versionName & versionCode with regexp
 	fmt.Println()
 	log.Infof("Updating versionName and versionCode in: %s", cfg.BuildGradlePth)
 
-	f, err := os.Open(cfg.BuildGradlePth)
+	versionUpdater := NewBuildGradleVersionUpdater(strings.NewReader(content))
+	res, err := versionUpdater.UpdateVersion(newVersionCode, cfg.VersionCodeOffset, cfg.NewVersionName)
 	if err != nil {
-		failf("Failed to read build.gradle file, error: %s", err)
+		failf("Failed to update versions: %s", err)
+	}
+	if res.UpdatedVersionCodes > 0 {
+		if err := validateVersionCodeRange(res.FinalVersionCode); err != nil {
+			failf("Invalid versionCode: %s", err)
+		}
 	}
 
-	versionUpdater := NewBuildGradleVersionUpdater(f)
-	res, err := versionUpdater.UpdateVersion(cfg.NewVersionCode, cfg.VersionCodeOffset, cfg.NewVersionName)
-	if err != nil {
-		failf("Failed to update versions: %s", err)
+	//
+	// set versionNameSuffix and applicationIdSuffix
+	if len(cfg.VersionNameSuffixes) > 0 || len(cfg.ApplicationIDSuffixes) > 0 {
+		fmt.Println()
+		log.Infof("Updating versionNameSuffix and applicationIdSuffix of build types and flavors")
+
+		var variantVersionNames string
+		res.NewContent, variantVersionNames, err = applySuffixEdits(cfg, res)
+		if err != nil {
+			failf("Failed to update suffixes: %s", err)
+		}
+		outputs["ANDROID_VARIANT_VERSION_NAMES"] = variantVersionNames
 	}
 
 	//
-	// export outputs
-	if err := exportOutputs(map[string]string{
-		"ANDROID_VERSION_NAME": removeQuotationMarks(res.FinalVersionName),
-		"ANDROID_VERSION_CODE": res.FinalVersionCode,
-	}); err != nil {
-		failf("Failed to export outputs, error: %s", err)
+	// update buildConfigField and resValue declarations
+	if len(cfg.BuildConfigFields) > 0 || len(cfg.ResValues) > 0 {
+		fmt.Println()
+		log.Infof("Updating buildConfigField and resValue declarations")
+
+		res.NewContent, err = updateGradleFields(cfg, res)
+		if err != nil {
+			failf("Failed to update buildConfigField and resValue declarations: %s", err)
+		}
+	}
+
+	//
+	// check versionCode and versionName overrides
+	if overrides := FindVersionOverrides(res.NewContent); len(overrides) > 0 {
+		fmt.Println()
+		log.Infof("Checking versionCode and versionName overrides")
+
+		res.NewContent, err = handleVersionOverrides(cfg, res, overrides)
+		if err != nil {
+			failf("Version override check failed: %s", err)
+		}
+	}
+
+	if len(cfg.StoreProfiles) > 0 {
+		fmt.Println()
+		log.Infof("Validating final versions against store profiles: %s", strings.Join(cfg.StoreProfiles, ", "))
+
+		if err := validateStoreProfiles(cfg.StoreProfiles, current, res); err != nil {
+			failf("Store profile validation failed:\n%s", err)
+		}
+	}
+
+	//
+	// evaluate version policy
+	if policyPth := versionPolicyPath(cfg); policyPth != "" {
+		fmt.Println()
+		log.Infof("Evaluating version policy: %s", policyPth)
+
+		if err := evaluateVersionPolicy(cfg, policyPth, current, res); err != nil {
+			failf("Version policy check failed: %s", err)
+		}
+	}
+
+	if cfg.PlayVersionCodeMode != "none" {
+		if code, err := strconv.Atoi(res.FinalVersionCode); err != nil || code <= playMaxVersionCode {
+			failf("Final versionCode (%s) must be greater than the greatest versionCode released to Google Play (%d)", res.FinalVersionCode, playMaxVersionCode)
+		}
+	}
+
+	//
+	// check version ledger
+	var ledger VersionLedger
+	var ledgerEntry LedgerEntry
+	if cfg.VersionLedgerType != "none" {
+		fmt.Println()
+		log.Infof("Checking versionCode against the %s version ledger: %s", cfg.VersionLedgerType, cfg.VersionLedgerPth)
+
+		ledger, ledgerEntry, err = checkVersionLedger(cfg, res)
+		if err != nil {
+			failf("Version ledger check failed: %s", err)
+		}
+	}
+
+	//
+	// compute split versionCodes
+	if cfg.SplitVersionCodes != "none" {
+		fmt.Println()
+		log.Infof("Computing split versionCodes with template: %s", cfg.SplitVersionCodes)
+
+		splitOutputs, err := computeSplitVersionCodes(cfg, res)
+		if err != nil {
+			failf("Failed to compute split versionCodes: %s", err)
+		}
+		for k, v := range splitOutputs {
+			outputs[k] = v
+		}
+	}
+
+	//
+	// encode flavor dimensions into versionCodes
+	if cfg.FlavorVersionCodeEncoding != "" {
+		fmt.Println()
+		log.Infof("Encoding flavor dimensions into versionCodes: %s", cfg.FlavorVersionCodeEncoding)
+
+		flavorCodes, err := encodeFlavorVersionCodes(cfg, res)
+		if err != nil {
+			failf("Failed to encode flavor versionCodes: %s", err)
+		}
+		outputs["ANDROID_FLAVOR_VERSION_CODES"] = flavorCodes
+	}
+
+	//
+	// resolve variant versions
+	if cfg.ExportVariantVersions {
+		fmt.Println()
+		log.Infof("Resolving the versions of every variant")
+
+		variantVersions, err := resolveVariantVersions(res)
+		if err != nil {
+			failf("Failed to resolve variant versions: %s", err)
+		}
+		outputs["ANDROID_VARIANT_VERSIONS"] = variantVersions
+	}
+
+	//
+	// derive form factor module versionCodes
+	var moduleCodes []ModuleVersionCode
+	var moduleContents map[string]string
+	if len(cfg.FormFactorModules) > 0 {
+		fmt.Println()
+		log.Infof("Deriving form factor module versionCodes")
+
+		moduleCodes, moduleContents, err = deriveFormFactorModules(cfg, res)
+		if err != nil {
+			failf("Failed to derive form factor module versionCodes: %s", err)
+		}
 	}
 
+	//
+	// write build.gradle, once every derived versionCode is validated
 	if err := fileutil.WriteStringToFile(cfg.BuildGradlePth, res.NewContent); err != nil {
 		failf("Failed to write build.gradle file, error: %s", err)
 	}
 
+	//
+	// update form factor modules
+	if len(moduleCodes) > 0 {
+		fmt.Println()
+		log.Infof("Updating form factor modules")
+
+		moduleCodesJSON, err := writeFormFactorModules(moduleCodes, moduleContents)
+		if err != nil {
+			failf("Failed to update form factor modules: %s", err)
+		}
+		outputs["ANDROID_MODULE_VERSION_CODES"] = moduleCodesJSON
+	}
+
+	//
+	// update string resources
+	if len(cfg.StringResources) > 0 {
+		fmt.Println()
+		log.Infof("Updating string resources")
+
+		if err := updateStringResources(cfg, res); err != nil {
+			failf("Failed to update string resources: %s", err)
+		}
+	}
+
+	//
+	// render template files
+	if len(cfg.TemplateFiles) > 0 {
+		fmt.Println()
+		log.Infof("Rendering template files")
+
+		if err := renderTemplateFiles(cfg, res); err != nil {
+			failf("Failed to render template files: %s", err)
+		}
+	}
+
+	//
+	// generate changelog
+	if cfg.GenerateChangelog && res.UpdatedVersionCodes+res.UpdatedVersionNames > 0 {
+		fmt.Println()
+		log.Infof("Generating changelog")
+
+		changelogOutputs, err := generateChangelog(cfg, removeQuotationMarks(res.FinalVersionName), res.FinalVersionCode)
+		if err != nil {
+			failf("Failed to generate changelog: %s", err)
+		}
+		for k, v := range changelogOutputs {
+			outputs[k] = v
+		}
+	}
+
+	//
+	// write Google Play changelogs
+	if len(cfg.PlayChangelogLocales) > 0 {
+		fmt.Println()
+		log.Infof("Writing Google Play changelogs for versionCode: %s", res.FinalVersionCode)
+
+		if err := writePlayChangelogs(cfg, removeQuotationMarks(res.FinalVersionName), res.FinalVersionCode); err != nil {
+			failf("Failed to write Google Play changelogs: %s", err)
+		}
+	}
+
+	//
+	// export outputs
+	outputs["ANDROID_VERSION_NAME"] = removeQuotationMarks(res.FinalVersionName)
+	outputs["ANDROID_VERSION_CODE"] = res.FinalVersionCode
+	if err := exportOutputs(outputs); err != nil {
+		failf("Failed to export outputs, error: %s", err)
+	}
+
+	//
+	// record version in the ledger, once every file is written and every output is exported,
+	// so that a retry of a failed build is not rejected by its own entry
+	if ledger != nil {
+		if err := ledger.Append(ledgerEntry); err != nil {
+			failf("Failed to record version in the ledger: %s", err)
+		}
+		log.Printf("recorded versionCode %d in the version ledger", ledgerEntry.VersionCode)
+	}
+
 	fmt.Println()
 	log.Donef("%d versionCode updated", res.UpdatedVersionCodes)
 	log.Donef("%d versionName updated", res.UpdatedVersionNames)
 }
 
+// versionPlaceholders returns the values of the {build}, {versionName} and {versionCode} placeholders.
+func versionPlaceholders(cfg config, res UpdateResult) map[string]string {
+	return map[string]string{
+		"build":       cfg.BuildNumber,
+		"versionName": removeQuotationMarks(res.FinalVersionName),
+		"versionCode": res.FinalVersionCode,
+	}
+}
+
+// parseNamedValues parses <name>=<template> items and expands the templates with the version placeholders.
+func parseNamedValues(kind string, items []string, versions map[string]string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, item := range items {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid %s (%s), expected <name>=<value>", kind, item)
+		}
+		value, err := placeholders.Expand(parts[1], placeholderValues(versions))
+		if err != nil {
+			return nil, fmt.Errorf("%s (%s): %s", kind, item, err)
+		}
+		values[strings.TrimSpace(parts[0])] = value
+	}
+	return values, nil
+}
+
+// quoteGradleString wraps the value in double quotes, unless it is already quoted.
+// It reports whether the quotation marks were added.
+func quoteGradleString(value string) (string, bool) {
+	if strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value, false
+	}
+	return `"` + strings.TrimSuffix(strings.TrimPrefix(value, `"`), `"`) + `"`, true
+}
+
 func removeQuotationMarks(value string) string {
 	return strings.Trim(value, `"'`)
 }
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+var (
+	// versionOverrideRegexp matches the legacy variant API output.versionCodeOverride = ... assignments.
+	versionOverrideRegexp = regexp.MustCompile(`(?m)\bversion(Code|Name)Override[ \t]*=[ \t]*(.*?)[ \t]*;?[ \t]*(?://.*)?$`)
+	// versionPropertySetRegexp matches the AGP Variant API output.versionCode.set(...) calls.
+	versionPropertySetRegexp = regexp.MustCompile(`\.version(Code|Name)\.set\s*\(`)
+	// versionReferenceRegexp matches expressions derived from the versionCode or versionName of the build script.
+	versionReferenceRegexp = regexp.MustCompile(`\bversion(?:Code|Name)\b`)
+)
+
+// VersionOverride is a late override of the versionCode or versionName of variant outputs,
+// which replaces the defaultConfig values at build time.
+type VersionOverride struct {
+	// Property is versionCode or versionName.
+	Property   string
+	Line       int
+	Expression string
+	// Start and End are the offsets of the expression in the build.gradle content.
+	Start int
+	End   int
+}
+
+// Literal reports whether the override assigns a literal, which can be rewritten.
+func (o VersionOverride) Literal() bool {
+	_, ok := gradleLiteral(o.Expression)
+	return ok
+}
+
+// Derived reports whether the override is computed from the versionCode or versionName of the build script,
+// like versionCodes.get(abi) * 1000 + defaultConfig.versionCode, so it follows the updated values.
+func (o VersionOverride) Derived() bool {
+	return versionReferenceRegexp.MatchString(o.Expression)
+}
+
+// FindVersionOverrides returns the versionCodeOverride and versionNameOverride assignments
+// and the AGP Variant API versionCode.set() and versionName.set() calls of the build.gradle content.
+// Commented out lines are skipped.
+func FindVersionOverrides(content string) []VersionOverride {
+	var overrides []VersionOverride
+	for _, m := range versionOverrideRegexp.FindAllStringSubmatchIndex(content, -1) {
+		overrides = append(overrides, newVersionOverride(content, "version"+content[m[2]:m[3]], m[4], m[5]))
+	}
+
+	for _, m := range versionPropertySetRegexp.FindAllStringSubmatchIndex(content, -1) {
+		start := m[1]
+		end := closingParenthesis(content, start)
+		if end < 0 {
+			continue
+		}
+		expression := content[start:end]
+		trimmedStart := start + len(expression) - len(strings.TrimLeft(expression, " \t"))
+		trimmedEnd := end - (len(expression) - len(strings.TrimRight(expression, " \t")))
+		overrides = append(overrides, newVersionOverride(content, "version"+content[m[2]:m[3]], trimmedStart, trimmedEnd))
+	}
+
+	var active []VersionOverride
+	for _, o := range overrides {
+		lineStart := strings.LastIndex(content[:o.Start], "\n") + 1
+		line := strings.TrimSpace(content[lineStart:o.Start])
+		if strings.HasPrefix(line, "//") || strings.HasPrefix(line, "*") || strings.HasPrefix(line, "/*") {
+			continue
+		}
+		active = append(active, o)
+	}
+	sort.SliceStable(active, func(i, j int) bool { return active[i].Start < active[j].Start })
+	return active
+}
+
+func newVersionOverride(content, property string, start, end int) VersionOverride {
+	return VersionOverride{
+		Property:   property,
+		Line:       strings.Count(content[:start], "\n") + 1,
+		Expression: content[start:end],
+		Start:      start,
+		End:        end,
+	}
+}
+
+// closingParenthesis returns the offset of the parenthesis closing the one opened before from, or -1.
+func closingParenthesis(content string, from int) int {
+	depth := 1
+	for i := from; i < len(content); i++ {
+		switch content[i] {
+		case '"', '\'':
+			i = skipString(content, i)
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// RewriteVersionOverrides replaces the literal overrides of the given properties with the new values,
+// empty values are not rewritten. It returns the updated content and the rewritten overrides.
+func RewriteVersionOverrides(content string, overrides []VersionOverride, versionCode, versionName string) (string, []VersionOverride) {
+	var rewritten []VersionOverride
+	for i := len(overrides) - 1; i >= 0; i-- {
+		o := overrides[i]
+		if !o.Literal() {
+			continue
+		}
+
+		value := ""
+		switch {
+		case o.Property == "versionCode" && versionCode != "":
+			value = versionCode
+		case o.Property == "versionName" && versionName != "":
+			value = `"` + versionName + `"`
+		default:
+			continue
+		}
+
+		content = content[:o.Start] + value + content[o.End:]
+		rewritten = append([]VersionOverride{o}, rewritten...)
+	}
+	return content, rewritten
+}
+
+// handleVersionOverrides rewrites the literal overrides if enabled and warns about every remaining override
+// which replaces an updated value at build time. In strict mode it fails instead.
+// It returns the build.gradle content with the rewritten overrides.
+func handleVersionOverrides(cfg config, res UpdateResult, overrides []VersionOverride) (string, error) {
+	updated := map[string]string{}
+	if res.UpdatedVersionCodes > 0 {
+		updated["versionCode"] = res.FinalVersionCode
+	}
+	if res.UpdatedVersionNames > 0 {
+		updated["versionName"] = removeQuotationMarks(res.FinalVersionName)
+	}
+
+	content := res.NewContent
+	rewritten := map[int]bool{}
+	if cfg.RewriteVersionOverrides {
+		var rewrittenOverrides []VersionOverride
+		content, rewrittenOverrides = RewriteVersionOverrides(content, overrides, updated["versionCode"], updated["versionName"])
+		for _, o := range rewrittenOverrides {
+			rewritten[o.Start] = true
+			log.Printf("line %d: %s override rewritten: %s -> %s", o.Line, o.Property, o.Expression, updated[o.Property])
+		}
+	}
+
+	ignored := 0
+	for _, o := range overrides {
+		switch {
+		case rewritten[o.Start]:
+		case updated[o.Property] == "":
+			log.Printf("line %d: %s override of a value not updated by the step: %s", o.Line, o.Property, o.Expression)
+		case o.Derived():
+			log.Printf("line %d: %s override derived from the build script: %s", o.Line, o.Property, o.Expression)
+		default:
+			ignored++
+			log.Warnf("line %d: %s override replaces the updated %s at build time: %s", o.Line, o.Property, o.Property, o.Expression)
+		}
+	}
+
+	if ignored > 0 {
+		if cfg.VersionOverrideCheck == "strict" {
+			return "", fmt.Errorf("%d override(s) replace the updated versions at build time", ignored)
+		}
+		log.Warnf("%d override(s) replace the updated versions at build time, the updated values will not be used for the affected outputs", ignored)
+	}
+	return content, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testOverridesBuildGradle = `android {
+    applicationVariants.all { variant ->
+        variant.outputs.each { output ->
+            output.versionCodeOverride = 42 // pinned
+            output.versionNameOverride = "1.0.0"
+            // output.versionCodeOverride = 1
+            output.versionCodeOverride = versionCodes.get(abi) * 1000 + variant.versionCode
+        }
+    }
+}
+
+androidComponents {
+    onVariants(selector().all()) { variant ->
+        variant.outputs.forEach { o -> o.versionCode.set(computeCode(o, "x)")) }
+        variant.outputs.forEach { it.versionName.set( "2.0" ) }
+    }
+}`
+
+func TestFindVersionOverrides(t *testing.T) {
+	var got []string
+	for _, o := range FindVersionOverrides(testOverridesBuildGradle) {
+		if testOverridesBuildGradle[o.Start:o.End] != o.Expression {
+			t.Errorf("override offsets do not match its expression: %q", testOverridesBuildGradle[o.Start:o.End])
+		}
+		got = append(got, o.Property+"="+o.Expression)
+	}
+	want := []string{
+		"versionCode=42",
+		`versionName="1.0.0"`,
+		"versionCode=versionCodes.get(abi) * 1000 + variant.versionCode",
+		`versionCode=computeCode(o, "x)")`,
+		`versionName="2.0"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindVersionOverrides() = %q, want %q", got, want)
+	}
+}
+
+func TestRewriteVersionOverrides(t *testing.T) {
+	overrides := FindVersionOverrides(testOverridesBuildGradle)
+	content, rewritten := RewriteVersionOverrides(testOverridesBuildGradle, overrides, "100", "")
+	if len(rewritten) != 1 || rewritten[0].Line != 4 {
+		t.Fatalf("RewriteVersionOverrides() rewritten = %+v", rewritten)
+	}
+
+	var got []string
+	for _, o := range FindVersionOverrides(content) {
+		got = append(got, o.Expression)
+	}
+	want := []string{"100", `"1.0.0"`, "versionCodes.get(abi) * 1000 + variant.versionCode", `computeCode(o, "x)")`, `"2.0"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RewriteVersionOverrides() overrides = %q, want %q", got, want)
+	}
+	if overrides[2].Literal() || !overrides[2].Derived() || overrides[3].Derived() {
+		t.Errorf("unexpected Literal() or Derived() of %+v", overrides[2:4])
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// errUnknownPlaceholder is returned by placeholder resolvers for names without a value.
+var errUnknownPlaceholder = errors.New("unknown placeholder")
+
+var (
+	// placeholderRegexp matches {name} and {name|filter|filter:arg} placeholders,
+	// the optional leading $ identifies Groovy and Kotlin string interpolation, which is left as is.
+	placeholderRegexp = regexp.MustCompile(`(\$?){([A-Za-z0-9_][A-Za-z0-9_.]*)((?:\|[a-z]+(?::\d+)?)*)}`)
+	// fileTemplatePlaceholderRegexp matches the {{name}} and {{name|filter}} placeholders of template files.
+	fileTemplatePlaceholderRegexp = regexp.MustCompile(`(){{\s*([A-Za-z0-9_.]+)((?:\|[a-z]+(?::\d+)?)*)\s*}}`)
+	slugInvalidRegexp             = regexp.MustCompile(`[^a-z0-9.]+`)
+)
+
+// PlaceholderTemplate substitutes the placeholders of a template syntax.
+// The pattern has to capture the string interpolation prefix, the name and the filters of the placeholder.
+type PlaceholderTemplate struct {
+	pattern *regexp.Regexp
+	// keepUnknown leaves placeholders without a value as is, instead of failing.
+	keepUnknown bool
+	// check validates the substituted values, nil accepts every value.
+	check func(value string) error
+}
+
+var (
+	// placeholders are the {name} placeholders of inputs, unknown names are errors.
+	placeholders = PlaceholderTemplate{pattern: placeholderRegexp}
+	// releaseNotePlaceholders are the {name} placeholders of release notes, which can contain other braces.
+	releaseNotePlaceholders = PlaceholderTemplate{pattern: placeholderRegexp, keepUnknown: true}
+	// fileTemplatePlaceholders are the {{name}} placeholders of template files.
+	fileTemplatePlaceholders = PlaceholderTemplate{pattern: fileTemplatePlaceholderRegexp}
+)
+
+// placeholderValues returns a resolver of the given values.
+func placeholderValues(values map[string]string) func(name string) (string, error) {
+	return func(name string) (string, error) {
+		if value, ok := values[name]; ok {
+			return value, nil
+		}
+		return "", errUnknownPlaceholder
+	}
+}
+
+// Expand substitutes the placeholders of the template with the values returned by resolve, after applying the filters.
+// Every failing placeholder is reported.
+func (p PlaceholderTemplate) Expand(template string, resolve func(name string) (string, error)) (string, error) {
+	var errs []string
+	expanded := p.pattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		match := p.pattern.FindStringSubmatch(placeholder)
+		if match[1] != "" {
+			return placeholder
+		}
+
+		value, err := resolve(match[2])
+		if err == errUnknownPlaceholder && p.keepUnknown {
+			return placeholder
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", placeholder, err))
+			return ""
+		}
+		for _, filter := range strings.Split(strings.TrimPrefix(match[3], "|"), "|") {
+			if filter == "" {
+				continue
+			}
+			if value, err = applyPlaceholderFilter(value, filter); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", placeholder, err))
+				return ""
+			}
+		}
+
+		if p.check != nil {
+			if err := p.check(value); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", placeholder, err))
+				return ""
+			}
+		}
+		return value
+	})
+	if len(errs) > 0 {
+		return "", fmt.Errorf("failed to expand placeholder(s):\n- %s", strings.Join(errs, "\n- "))
+	}
+	return expanded, nil
+}
+
+// HasPlaceholders reports whether the template contains placeholders to expand.
+func (p PlaceholderTemplate) HasPlaceholders(template string) bool {
+	for _, match := range p.pattern.FindAllStringSubmatch(template, -1) {
+		if match[1] == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPlaceholderFilter applies the slug, upper, lower or truncate:<length> filter to the value.
+func applyPlaceholderFilter(value, filter string) (string, error) {
+	name, arg := filter, ""
+	if i := strings.Index(filter, ":"); i >= 0 {
+		name, arg = filter[:i], filter[i+1:]
+	}
+
+	switch name {
+	case "slug":
+		return strings.Trim(slugInvalidRegexp.ReplaceAllString(strings.ToLower(value), "-"), "-."), nil
+	case "upper":
+		return strings.ToUpper(value), nil
+	case "lower":
+		return strings.ToLower(value), nil
+	case "truncate":
+		length, err := strconv.Atoi(arg)
+		if err != nil || length <= 0 {
+			return "", fmt.Errorf("truncate filter requires a positive length, like truncate:10")
+		}
+		if runes := []rune(value); len(runes) > length {
+			return string(runes[:length]), nil
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown filter (%s), available filters: slug, upper, lower, truncate:<length>", name)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestPlaceholderTemplate_Expand(t *testing.T) {
+	resolve := placeholderValues(map[string]string{"versionName": "1.2.0", "branch": "Feature/Login"})
+
+	tests := []struct {
+		name     string
+		template PlaceholderTemplate
+		input    string
+		want     string
+		wantErr  bool
+	}{
+		{name: "Values and filters", template: placeholders, input: "{versionName}-{branch|slug}", want: "1.2.0-feature-login"},
+		{name: "String interpolation is kept", template: placeholders, input: "${versionName}-{versionName}", want: "${versionName}-1.2.0"},
+		{name: "Unknown placeholder", template: placeholders, input: "{versionName}-{locale}", wantErr: true},
+		{name: "Unknown placeholder is kept", template: releaseNotePlaceholders, input: "{versionName}: fixed {settings} screen", want: "1.2.0: fixed {settings} screen"},
+		{name: "Unknown filter", template: releaseNotePlaceholders, input: "{branch|camel}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.template.Expand(tt.input, resolve)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PlaceholderTemplate.Expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("PlaceholderTemplate.Expand() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	// playAPIDefaultBaseURL is the base URL of the Google Play Developer API.
+	playAPIDefaultBaseURL = "https://androidpublisher.googleapis.com"
+	playAPIScope          = "https://www.googleapis.com/auth/androidpublisher"
+)
+
+// PlayTracks is the response of the edits.tracks.list endpoint.
+type PlayTracks struct {
+	Tracks []struct {
+		Track    string `json:"track"`
+		Releases []struct {
+			Name         string   `json:"name"`
+			Status       string   `json:"status"`
+			VersionCodes []string `json:"versionCodes"`
+		} `json:"releases"`
+	} `json:"tracks"`
+}
+
+// MaxVersionCode returns the greatest versionCode released to any track, or 0 if there is none.
+func (t PlayTracks) MaxVersionCode() (int, error) {
+	max := 0
+	for _, track := range t.Tracks {
+		for _, release := range track.Releases {
+			for _, code := range release.VersionCodes {
+				n, err := strconv.Atoi(code)
+				if err != nil {
+					return 0, fmt.Errorf("invalid versionCode (%s) on track (%s): %s", code, track.Track, err)
+				}
+				if n > max {
+					max = n
+				}
+			}
+		}
+	}
+	return max, nil
+}
+
+// nextPlayVersionCode returns the versionCode following the greatest released one.
+// Released versionCodes are final, so the offset, which is added to the generated versionCode, is subtracted.
+func nextPlayVersionCode(maxVersionCode, versionCodeOffset int) (int, error) {
+	code := maxVersionCode + 1 - versionCodeOffset
+	if code <= 0 {
+		return 0, fmt.Errorf("offset (%d) is greater than the next versionCode (%d)", versionCodeOffset, maxVersionCode+1)
+	}
+	return code, nil
+}
+
+// ReadPlayTracksFixture reads an edits.tracks.list response from a file, for offline use.
+func ReadPlayTracksFixture(pth string) (PlayTracks, error) {
+	content, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return PlayTracks{}, err
+	}
+
+	var tracks PlayTracks
+	if err := json.Unmarshal(content, &tracks); err != nil {
+		return PlayTracks{}, fmt.Errorf("failed to parse tracks fixture (%s): %s", pth, err)
+	}
+	return tracks, nil
+}
+
+// ServiceAccount is the subset of a Google service account JSON key used for authentication.
+type ServiceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// ReadServiceAccount reads a service account JSON key file.
+func ReadServiceAccount(pth string) (ServiceAccount, error) {
+	content, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return ServiceAccount{}, err
+	}
+
+	var sa ServiceAccount
+	if err := json.Unmarshal(content, &sa); err != nil {
+		return ServiceAccount{}, fmt.Errorf("failed to parse service account (%s): %s", pth, err)
+	}
+	if sa.ClientEmail == "" || sa.PrivateKey == "" || sa.TokenURI == "" {
+		return ServiceAccount{}, fmt.Errorf("service account (%s) is missing client_email, private_key or token_uri", pth)
+	}
+	return sa, nil
+}
+
+// assertion returns the signed JWT the service account exchanges for an access token.
+func (sa ServiceAccount) assertion(now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(sa.PrivateKey))
+	if block == nil {
+		return "", errors.New("private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("failed to parse private key: %s", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("private key is not an RSA key")
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   sa.ClientEmail,
+		"scope": playAPIScope,
+		"aud":   sa.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// PlayAPIClient queries a Google Play Developer API compatible server.
+type PlayAPIClient struct {
+	baseURL        string
+	serviceAccount ServiceAccount
+	httpClient     *http.Client
+	now            func() time.Time
+}
+
+// NewPlayAPIClient constructs a new PlayAPIClient.
+func NewPlayAPIClient(baseURL string, serviceAccount ServiceAccount, httpClient *http.Client) PlayAPIClient {
+	return PlayAPIClient{baseURL: strings.TrimSuffix(baseURL, "/"), serviceAccount: serviceAccount, httpClient: httpClient, now: time.Now}
+}
+
+// Tracks lists the tracks of the app in a temporary edit, which is deleted afterwards.
+func (c PlayAPIClient) Tracks(packageName string) (PlayTracks, error) {
+	token, err := c.accessToken()
+	if err != nil {
+		return PlayTracks{}, fmt.Errorf("failed to authenticate: %s", err)
+	}
+
+	editsURL := fmt.Sprintf("%s/androidpublisher/v3/applications/%s/edits", c.baseURL, url.PathEscape(packageName))
+
+	var edit struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(http.MethodPost, editsURL, token, &edit); err != nil {
+		return PlayTracks{}, fmt.Errorf("failed to create edit: %s", err)
+	}
+
+	var tracks PlayTracks
+	tracksErr := c.do(http.MethodGet, editsURL+"/"+url.PathEscape(edit.ID)+"/tracks", token, &tracks)
+	if err := c.do(http.MethodDelete, editsURL+"/"+url.PathEscape(edit.ID), token, nil); err != nil && tracksErr == nil {
+		return PlayTracks{}, fmt.Errorf("failed to delete edit: %s", err)
+	}
+	if tracksErr != nil {
+		return PlayTracks{}, fmt.Errorf("failed to list tracks: %s", tracksErr)
+	}
+	return tracks, nil
+}
+
+func (c PlayAPIClient) accessToken() (string, error) {
+	assertion, err := c.serviceAccount.assertion(c.now())
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.httpClient.PostForm(c.serviceAccount.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := decodeResponse(resp, &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("token response has no access_token")
+	}
+	return token.AccessToken, nil
+}
+
+func (c PlayAPIClient) do(method, url, token string, v interface{}) error {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(resp, v)
+}
+
+func decodeResponse(resp *http.Response, v interface{}) error {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s: %s", resp.Request.Method, resp.Request.URL, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if v == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, v)
+}
+
+// lookupPlayMaxVersionCode returns the greatest versionCode released to any track,
+// read from the tracks fixture if given, otherwise from the Google Play Developer API compatible server.
+func lookupPlayMaxVersionCode(cfg config) (int, error) {
+	var tracks PlayTracks
+	if cfg.PlayTracksFixturePth != "" {
+		log.Printf("reading tracks fixture: %s", cfg.PlayTracksFixturePth)
+
+		var err error
+		if tracks, err = ReadPlayTracksFixture(cfg.PlayTracksFixturePth); err != nil {
+			return 0, err
+		}
+	} else {
+		if cfg.PlayPackageName == "" || cfg.PlayServiceAccountJSONPth == "" {
+			return 0, fmt.Errorf("play_package_name and play_service_account_json_path are required without a tracks fixture")
+		}
+
+		sa, err := ReadServiceAccount(cfg.PlayServiceAccountJSONPth)
+		if err != nil {
+			return 0, err
+		}
+
+		baseURL := cfg.PlayAPIBaseURL
+		if baseURL == "" {
+			baseURL = playAPIDefaultBaseURL
+		}
+		log.Printf("querying tracks of %s: %s", cfg.PlayPackageName, baseURL)
+
+		client := NewPlayAPIClient(baseURL, sa, &http.Client{Timeout: 30 * time.Second})
+		if tracks, err = client.Tracks(cfg.PlayPackageName); err != nil {
+			return 0, err
+		}
+	}
+	return tracks.MaxVersionCode()
+}
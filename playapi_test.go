@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPlayTracks = `{"tracks": [
+	{"track": "production", "releases": [{"status": "completed", "versionCodes": ["40", "41"]}]},
+	{"track": "beta", "releases": [{"status": "completed", "versionCodes": ["45"]}, {"status": "draft"}]}
+]}`
+
+func TestPlayTracks_MaxVersionCode(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "tracks.json")
+	if err := os.WriteFile(pth, []byte(testPlayTracks), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tracks, err := ReadPlayTracksFixture(pth)
+	if err != nil {
+		t.Fatalf("ReadPlayTracksFixture() error = %v", err)
+	}
+	got, err := tracks.MaxVersionCode()
+	if err != nil {
+		t.Fatalf("PlayTracks.MaxVersionCode() error = %v", err)
+	}
+	if got != 45 {
+		t.Errorf("PlayTracks.MaxVersionCode() = %v, want %v", got, 45)
+	}
+}
+
+func Test_nextPlayVersionCode(t *testing.T) {
+	tests := []struct {
+		name              string
+		maxVersionCode    int
+		versionCodeOffset int
+		want              int
+		wantErr           bool
+	}{
+		{name: "Without offset", maxVersionCode: 42, want: 43},
+		{name: "Offset is subtracted", maxVersionCode: 5042, versionCodeOffset: 5000, want: 43},
+		{name: "Offset exceeds the next versionCode", maxVersionCode: 42, versionCodeOffset: 5000, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nextPlayVersionCode(tt.maxVersionCode, tt.versionCodeOffset)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("nextPlayVersionCode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("nextPlayVersionCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlayAPIClient_Tracks(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.FormValue("assertion"), ".")
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			http.Error(w, "invalid assertion", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token": "token"}`))
+	})
+	mux.HandleFunc("/androidpublisher/v3/applications/com.example/edits/", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(testPlayTracks))
+		}
+	})
+	mux.HandleFunc("/androidpublisher/v3/applications/com.example/edits", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": "edit-1"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sa := ServiceAccount{
+		ClientEmail: "ci@example.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		TokenURI:    server.URL + "/token",
+	}
+
+	tracks, err := NewPlayAPIClient(server.URL+"/", sa, server.Client()).Tracks("com.example")
+	if err != nil {
+		t.Fatalf("PlayAPIClient.Tracks() error = %v", err)
+	}
+	if got, _ := tracks.MaxVersionCode(); got != 45 {
+		t.Errorf("PlayAPIClient.Tracks() max versionCode = %v, want %v", got, 45)
+	}
+
+	want := []string{
+		"POST /androidpublisher/v3/applications/com.example/edits",
+		"GET /androidpublisher/v3/applications/com.example/edits/edit-1/tracks",
+		"DELETE /androidpublisher/v3/applications/com.example/edits/edit-1",
+	}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// playChangelogMaxLength is the greatest number of characters Google Play allows in the release notes of a locale.
+const playChangelogMaxLength = 500
+
+// PlayChangelogWriter writes the fastlane supply changelog files
+// (<metadata dir>/<locale>/changelogs/<versionCode>.txt) of a versionCode.
+type PlayChangelogWriter struct {
+	metadataDir string
+	overwrite   bool
+}
+
+// NewPlayChangelogWriter constructs a new PlayChangelogWriter.
+func NewPlayChangelogWriter(metadataDir string, overwrite bool) PlayChangelogWriter {
+	return PlayChangelogWriter{metadataDir: metadataDir, overwrite: overwrite}
+}
+
+// Path returns the changelog file path of the locale and versionCode.
+func (w PlayChangelogWriter) Path(locale, versionCode string) string {
+	return filepath.Join(w.metadataDir, locale, "changelogs", versionCode+".txt")
+}
+
+// Write writes the changelog of the locale and versionCode.
+// It returns false without writing if the file already exists and overwriting is disabled.
+func (w PlayChangelogWriter) Write(locale, versionCode, changelog string) (bool, error) {
+	if _, err := strconv.Atoi(versionCode); err != nil {
+		return false, fmt.Errorf("versionCode (%s) is not an integer", versionCode)
+	}
+
+	changelog = strings.TrimSpace(changelog)
+	if changelog == "" {
+		return false, fmt.Errorf("changelog of locale (%s) is empty", locale)
+	}
+	if length := utf8.RuneCountInString(changelog); length > playChangelogMaxLength {
+		return false, fmt.Errorf("changelog of locale (%s) is %d characters long, Google Play allows at most %d", locale, length, playChangelogMaxLength)
+	}
+
+	pth := w.Path(locale, versionCode)
+	if exists, err := pathutil.IsPathExists(pth); err != nil {
+		return false, err
+	} else if exists && !w.overwrite {
+		return false, nil
+	}
+
+	if err := pathutil.EnsureDirExist(filepath.Dir(pth)); err != nil {
+		return false, err
+	}
+	if err := fileutil.WriteStringToFile(pth, changelog+"\n"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writePlayChangelogs writes the fastlane supply changelog file of every configured locale,
+// rendered from the source file if given, otherwise from the template.
+func writePlayChangelogs(cfg config, versionName, versionCode string) error {
+	writer := NewPlayChangelogWriter(cfg.PlayChangelogMetadataDir, cfg.PlayChangelogOverwrite)
+	for _, locale := range cfg.PlayChangelogLocales {
+		values := placeholderValues(map[string]string{"versionName": versionName, "versionCode": versionCode, "locale": locale})
+
+		var changelog string
+		if cfg.PlayChangelogSourcePth != "" {
+			sourcePth, err := placeholders.Expand(cfg.PlayChangelogSourcePth, values)
+			if err != nil {
+				return fmt.Errorf("changelog source path (%s): %s", cfg.PlayChangelogSourcePth, err)
+			}
+			notes, err := fileutil.ReadStringFromFile(sourcePth)
+			if err != nil {
+				return err
+			}
+			if changelog, err = releaseNotePlaceholders.Expand(notes, values); err != nil {
+				return fmt.Errorf("changelog source (%s): %s", sourcePth, err)
+			}
+		} else {
+			var err error
+			if changelog, err = placeholders.Expand(cfg.PlayChangelogTemplate, values); err != nil {
+				return fmt.Errorf("changelog template (%s): %s", cfg.PlayChangelogTemplate, err)
+			}
+		}
+
+		written, err := writer.Write(locale, versionCode, changelog)
+		if err != nil {
+			return err
+		}
+		if written {
+			log.Printf("changelog written: %s", writer.Path(locale, versionCode))
+		} else {
+			log.Warnf("Changelog already exists, not overwriting: %s", writer.Path(locale, versionCode))
+		}
+	}
+	return nil
+}
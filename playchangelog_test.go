@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlayChangelogWriter_Write(t *testing.T) {
+	tests := []struct {
+		name        string
+		overwrite   bool
+		existing    string
+		versionCode string
+		changelog   string
+
+		wantWritten bool
+		wantContent string
+		wantErr     bool
+	}{
+		{
+			name:        "Writes new changelog",
+			versionCode: "42",
+			changelog:   "Bug fixes\n",
+			wantWritten: true,
+			wantContent: "Bug fixes\n",
+		},
+		{
+			name:        "Keeps existing changelog",
+			existing:    "Old notes\n",
+			versionCode: "42",
+			changelog:   "Bug fixes",
+			wantContent: "Old notes\n",
+		},
+		{
+			name:        "Overwrites existing changelog",
+			overwrite:   true,
+			existing:    "Old notes\n",
+			versionCode: "42",
+			changelog:   "Bug fixes",
+			wantWritten: true,
+			wantContent: "Bug fixes\n",
+		},
+		{
+			name:        "500 characters are allowed",
+			versionCode: "42",
+			changelog:   strings.Repeat("é", 500),
+			wantWritten: true,
+			wantContent: strings.Repeat("é", 500) + "\n",
+		},
+		{
+			name:        "Longer than 500 characters",
+			versionCode: "42",
+			changelog:   strings.Repeat("a", 501),
+			wantErr:     true,
+		},
+		{
+			name:        "Empty changelog",
+			versionCode: "42",
+			changelog:   "  \n",
+			wantErr:     true,
+		},
+		{
+			name:        "Not an integer versionCode",
+			versionCode: "rootProject.ext.versionCode",
+			changelog:   "Bug fixes",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewPlayChangelogWriter(t.TempDir(), tt.overwrite)
+			pth := w.Path("en-US", tt.versionCode)
+			if tt.existing != "" {
+				if _, err := w.Write("en-US", tt.versionCode, tt.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			written, err := w.Write("en-US", tt.versionCode, tt.changelog)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PlayChangelogWriter.Write() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if written != tt.wantWritten {
+				t.Errorf("PlayChangelogWriter.Write() = %v, want %v", written, tt.wantWritten)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if filepath.Base(pth) != tt.versionCode+".txt" {
+				t.Errorf("PlayChangelogWriter.Path() = %s", pth)
+			}
+			content, err := os.ReadFile(pth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("changelog content = %q, want %q", content, tt.wantContent)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// Policy rule levels.
+const (
+	policyLevelWarn  = "warn"
+	policyLevelError = "error"
+)
+
+// PolicyRule is a rule of the version policy file.
+type PolicyRule struct {
+	Rule  string `json:"rule"`
+	Level string `json:"level"`
+	// BranchPattern matches the release branches, with major and minor named groups (release_branch_version_name).
+	BranchPattern string `json:"branch_pattern,omitempty"`
+}
+
+// Policy is the version policy file committed next to build.gradle.
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyInput are the versions the policy is evaluated against.
+type PolicyInput struct {
+	OldVersionCode string
+	NewVersionCode string
+	OldVersionName string
+	NewVersionName string
+	Branch         string
+}
+
+// PolicyViolation is a violated rule.
+type PolicyViolation struct {
+	Rule    string
+	Level   string
+	Message string
+}
+
+func (v PolicyViolation) String() string {
+	return fmt.Sprintf("[%s] %s: %s", v.Level, v.Rule, v.Message)
+}
+
+type policyRuleFn func(rule PolicyRule, in PolicyInput) (string, error)
+
+// policyRules are the available rules by name. A rule returns the violation message, or empty if it is satisfied.
+var policyRules = map[string]policyRuleFn{
+	"semver_version_name": func(_ PolicyRule, in PolicyInput) (string, error) {
+		if _, err := ParseSemver(in.NewVersionName); err != nil {
+			return err.Error(), nil
+		}
+		return "", nil
+	},
+	"no_version_name_downgrade": func(_ PolicyRule, in PolicyInput) (string, error) {
+		oldVersion, err := ParseSemver(in.OldVersionName)
+		if err != nil {
+			// a non semantic old version can not be compared
+			return "", nil
+		}
+		newVersion, err := ParseSemver(in.NewVersionName)
+		if err != nil {
+			return err.Error(), nil
+		}
+		if newVersion.Compare(oldVersion) < 0 {
+			return fmt.Sprintf("versionName (%s) is lower than the current versionName (%s)", in.NewVersionName, in.OldVersionName), nil
+		}
+		return "", nil
+	},
+	"increasing_version_code": func(_ PolicyRule, in PolicyInput) (string, error) {
+		newCode, err := strconv.Atoi(in.NewVersionCode)
+		if err != nil {
+			return fmt.Sprintf("versionCode (%s) is not an integer", in.NewVersionCode), nil
+		}
+		if oldCode, err := strconv.Atoi(in.OldVersionCode); err == nil && newCode <= oldCode {
+			return fmt.Sprintf("versionCode (%d) is not greater than the current versionCode (%d)", newCode, oldCode), nil
+		}
+		return "", nil
+	},
+	"release_branch_version_name": func(rule PolicyRule, in PolicyInput) (string, error) {
+		pattern, err := regexp.Compile(rule.BranchPattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid branch_pattern (%s): %s", rule.BranchPattern, err)
+		}
+		match := pattern.FindStringSubmatch(in.Branch)
+		if match == nil {
+			// not a release branch
+			return "", nil
+		}
+
+		version, err := ParseSemver(in.NewVersionName)
+		if err != nil {
+			return err.Error(), nil
+		}
+		want := fmt.Sprintf("%s.%s", match[pattern.SubexpIndex("major")], match[pattern.SubexpIndex("minor")])
+		if got := fmt.Sprintf("%d.%d", version.Major, version.Minor); got != want {
+			return fmt.Sprintf("versionName (%s) does not match the major.minor (%s) of release branch (%s)", in.NewVersionName, want, in.Branch), nil
+		}
+		return "", nil
+	},
+}
+
+// ReadPolicy reads and validates a version policy file.
+func ReadPolicy(pth string) (Policy, error) {
+	content, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(content, &policy); err != nil {
+		return Policy{}, fmt.Errorf("failed to parse policy (%s): %s", pth, err)
+	}
+
+	for i, rule := range policy.Rules {
+		if _, ok := policyRules[rule.Rule]; !ok {
+			return Policy{}, fmt.Errorf("policy (%s) rule %d: unknown rule (%s)", pth, i+1, rule.Rule)
+		}
+		if rule.Level != policyLevelWarn && rule.Level != policyLevelError {
+			return Policy{}, fmt.Errorf("policy (%s) rule %d: level (%s) must be %s or %s", pth, i+1, rule.Level, policyLevelWarn, policyLevelError)
+		}
+		if rule.Rule == "release_branch_version_name" {
+			pattern, err := regexp.Compile(rule.BranchPattern)
+			if err != nil {
+				return Policy{}, fmt.Errorf("policy (%s) rule %d: invalid branch_pattern (%s): %s", pth, i+1, rule.BranchPattern, err)
+			}
+			if pattern.SubexpIndex("major") < 0 || pattern.SubexpIndex("minor") < 0 {
+				return Policy{}, fmt.Errorf("policy (%s) rule %d: branch_pattern (%s) needs major and minor named groups", pth, i+1, rule.BranchPattern)
+			}
+		}
+	}
+	return policy, nil
+}
+
+// Evaluate evaluates every rule and returns all violations.
+func (p Policy) Evaluate(in PolicyInput) ([]PolicyViolation, error) {
+	var violations []PolicyViolation
+	for _, rule := range p.Rules {
+		fn, ok := policyRules[rule.Rule]
+		if !ok {
+			return nil, fmt.Errorf("unknown rule (%s)", rule.Rule)
+		}
+
+		message, err := fn(rule, in)
+		if err != nil {
+			return nil, fmt.Errorf("rule (%s): %s", rule.Rule, err)
+		}
+		if message != "" {
+			violations = append(violations, PolicyViolation{Rule: rule.Rule, Level: rule.Level, Message: message})
+		}
+	}
+	return violations, nil
+}
+
+// versionPolicyPath returns the configured policy file, or version_policy.json next to build.gradle if it exists.
+func versionPolicyPath(cfg config) string {
+	if cfg.VersionPolicyPth != "" {
+		return cfg.VersionPolicyPth
+	}
+
+	pth := filepath.Join(filepath.Dir(cfg.BuildGradlePth), "version_policy.json")
+	if exists, err := pathutil.IsPathExists(pth); err != nil || !exists {
+		return ""
+	}
+	return pth
+}
+
+// evaluateVersionPolicy logs every violation of the policy and fails if any of them is an error.
+func evaluateVersionPolicy(cfg config, policyPth string, current, res UpdateResult) error {
+	policy, err := ReadPolicy(policyPth)
+	if err != nil {
+		return err
+	}
+
+	branch := cfg.Branch
+	if branch == "" {
+		if branch, err = NewGitRepository(cfg.GitRepositoryPth).CurrentBranch(); err != nil {
+			log.Warnf("Failed to read the current branch: %s", err)
+		}
+	}
+
+	violations, err := policy.Evaluate(PolicyInput{
+		OldVersionCode: current.FinalVersionCode,
+		NewVersionCode: res.FinalVersionCode,
+		OldVersionName: removeQuotationMarks(current.FinalVersionName),
+		NewVersionName: removeQuotationMarks(res.FinalVersionName),
+		Branch:         branch,
+	})
+	if err != nil {
+		return err
+	}
+
+	errorCount := 0
+	for _, v := range violations {
+		if v.Level == policyLevelError {
+			errorCount++
+			log.Errorf("%s", v)
+		} else {
+			log.Warnf("%s", v)
+		}
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("%d of %d rule(s) violated with error level", errorCount, len(policy.Rules))
+	}
+	log.Printf("%d rule(s) evaluated, %d warning(s)", len(policy.Rules), len(violations))
+	return nil
+}
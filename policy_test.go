@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testPolicy = `{
+  "rules": [
+    {"rule": "semver_version_name", "level": "error"},
+    {"rule": "no_version_name_downgrade", "level": "warn"},
+    {"rule": "increasing_version_code", "level": "error"},
+    {"rule": "release_branch_version_name", "level": "error", "branch_pattern": "^release/(?P<major>\\d+)\\.(?P<minor>\\d+)$"}
+  ]
+}`
+
+func writeTestPolicy(t *testing.T, content string) string {
+	pth := filepath.Join(t.TempDir(), "version_policy.json")
+	if err := os.WriteFile(pth, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return pth
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	policy, err := ReadPolicy(writeTestPolicy(t, testPolicy))
+	if err != nil {
+		t.Fatalf("ReadPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		in   PolicyInput
+		want []PolicyViolation
+	}{
+		{
+			name: "Every rule satisfied",
+			in:   PolicyInput{OldVersionCode: "41", NewVersionCode: "42", OldVersionName: "1.4.2", NewVersionName: "1.5.0", Branch: "release/1.5"},
+		},
+		{
+			name: "Not a release branch",
+			in:   PolicyInput{OldVersionCode: "41", NewVersionCode: "42", OldVersionName: "1.4.2", NewVersionName: "2.0.0", Branch: "main"},
+		},
+		{
+			name: "Every violation is reported",
+			in:   PolicyInput{OldVersionCode: "42", NewVersionCode: "42", OldVersionName: "1.5.0", NewVersionName: "1.4.9", Branch: "release/1.5"},
+			want: []PolicyViolation{
+				{Rule: "no_version_name_downgrade", Level: "warn", Message: "versionName (1.4.9) is lower than the current versionName (1.5.0)"},
+				{Rule: "increasing_version_code", Level: "error", Message: "versionCode (42) is not greater than the current versionCode (42)"},
+				{Rule: "release_branch_version_name", Level: "error", Message: "versionName (1.4.9) does not match the major.minor (1.5) of release branch (release/1.5)"},
+			},
+		},
+		{
+			name: "Not a semantic versionName",
+			in:   PolicyInput{OldVersionCode: "rootProject.ext.versionCode", NewVersionCode: "42", OldVersionName: "rootProject.ext.versionName", NewVersionName: "1.5"},
+			want: []PolicyViolation{
+				{Rule: "semver_version_name", Level: "error", Message: "version (1.5) is not a semantic version (major.minor.patch[-prerelease][+build])"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Evaluate(tt.in)
+			if err != nil {
+				t.Fatalf("Policy.Evaluate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Policy.Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPolicy_invalid(t *testing.T) {
+	for _, content := range []string{
+		`{"rules": [{"rule": "unknown_rule", "level": "error"}]}`,
+		`{"rules": [{"rule": "semver_version_name", "level": "fatal"}]}`,
+		`{"rules": [{"rule": "release_branch_version_name", "level": "error", "branch_pattern": "^release/(\\d+)$"}]}`,
+		`{"rules": [`,
+	} {
+		if _, err := ReadPolicy(writeTestPolicy(t, content)); err == nil {
+			t.Errorf("ReadPolicy(%s) expected error", content)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// TemplateFile is a template file rendered to an output file.
+type TemplateFile struct {
+	Template string
+	Output   string
+}
+
+// ParseTemplateFiles parses <template>:<output> path pairs.
+func ParseTemplateFiles(items []string) ([]TemplateFile, error) {
+	var files []TemplateFile
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid template file pair (%s), expected <template>:<output>", item)
+		}
+		files = append(files, TemplateFile{Template: strings.TrimSpace(parts[0]), Output: strings.TrimSpace(parts[1])})
+	}
+	return files, nil
+}
+
+// versionTemplateValues returns the values of the {{versionName}}, {{versionCode}} and {{build}} placeholders,
+// and the {{semver.major}}, {{semver.minor}}, {{semver.patch}}, {{semver.prerelease}} and {{semver.build}} placeholders
+// if the versionName is a semantic version.
+func versionTemplateValues(versionName, versionCode, build string) map[string]string {
+	values := map[string]string{
+		"versionName": versionName,
+		"versionCode": versionCode,
+		"build":       build,
+	}
+	if v, err := ParseSemver(versionName); err == nil {
+		values["semver.major"] = strconv.Itoa(v.Major)
+		values["semver.minor"] = strconv.Itoa(v.Minor)
+		values["semver.patch"] = strconv.Itoa(v.Patch)
+		values["semver.prerelease"] = strings.Join(v.Prerelease, ".")
+		values["semver.build"] = v.Build
+	}
+	return values
+}
+
+// renderFileTemplate substitutes the {{name}} placeholders of the template with the given values.
+func renderFileTemplate(template string, values map[string]string) (string, error) {
+	return fileTemplatePlaceholders.Expand(template, placeholderValues(values))
+}
+
+// renderTemplateFiles renders the configured template files with the final versions, the values exported as ANDROID_VERSION_*,
+// and the commit hash of the repository.
+func renderTemplateFiles(cfg config, res UpdateResult) error {
+	files, err := ParseTemplateFiles(cfg.TemplateFiles)
+	if err != nil {
+		return err
+	}
+
+	values := versionTemplateValues(removeQuotationMarks(res.FinalVersionName), res.FinalVersionCode, cfg.BuildNumber)
+	repo := NewGitRepository(cfg.GitRepositoryPth)
+	if sha, err := repo.CommitHash(); err != nil {
+		log.Warnf("Failed to read the current commit, {{gitSha}} and {{gitShortSha}} are unavailable: %s", err)
+	} else {
+		values["gitSha"] = sha
+		if values["gitShortSha"], err = repo.ShortCommitHash(); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range files {
+		template, err := fileutil.ReadStringFromFile(f.Template)
+		if err != nil {
+			return err
+		}
+
+		rendered, err := renderFileTemplate(template, values)
+		if err != nil {
+			return fmt.Errorf("failed to render template (%s): %s", f.Template, err)
+		}
+
+		if err := pathutil.EnsureDirExist(filepath.Dir(f.Output)); err != nil {
+			return err
+		}
+		if err := fileutil.WriteStringToFile(f.Output, rendered); err != nil {
+			return err
+		}
+		log.Printf("%s -> %s", f.Template, f.Output)
+	}
+	return nil
+}
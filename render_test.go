@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_renderFileTemplate(t *testing.T) {
+	values := versionTemplateValues("1.3.0-rc.1+42", "2042", "42")
+	values["gitSha"] = "0123abc"
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Version values",
+			template: `{"name": "{{versionName}}", "code": {{ versionCode }}, "sha": "{{gitSha}}"}`,
+			want:     `{"name": "1.3.0-rc.1+42", "code": 2042, "sha": "0123abc"}`,
+		},
+		{
+			name:     "Semver components",
+			template: "{{semver.major}}.{{semver.minor}}.{{semver.patch}} {{semver.prerelease}} {{semver.build}}",
+			want:     "1.3.0 rc.1 42",
+		},
+		{
+			name:     "Single braces are kept",
+			template: "<script>var v = {code: {{versionCode}}};</script>",
+			want:     "<script>var v = {code: 2042};</script>",
+		},
+		{name: "Filters", template: "{{ versionName|slug }}", want: "1.3.0-rc.1-42"},
+		{name: "Unknown placeholder", template: "{{versionname}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderFileTemplate(tt.template, values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderFileTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("renderFileTemplate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := renderFileTemplate("{{semver.major}}", versionTemplateValues("1.3", "2042", "")); err == nil {
+		t.Errorf("renderFileTemplate() expected error for semver placeholder of a non semantic versionName")
+	}
+}
+
+func TestParseTemplateFiles(t *testing.T) {
+	got, err := ParseTemplateFiles([]string{"about.html.tmpl:app/src/main/assets/about.html", " version.json.tmpl : version.json "})
+	if err != nil {
+		t.Fatalf("ParseTemplateFiles() error = %s", err)
+	}
+	want := []TemplateFile{
+		{Template: "about.html.tmpl", Output: "app/src/main/assets/about.html"},
+		{Template: "version.json.tmpl", Output: "version.json"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTemplateFiles() = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseTemplateFiles([]string{"about.html.tmpl"}); err == nil {
+		t.Errorf("ParseTemplateFiles() expected error")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+var (
+	xmlCommentRegexp        = regexp.MustCompile(`(?s)<!--.*?-->`)
+	stringResourceTagRegexp = regexp.MustCompile(`<string\s[^>]*?\bname\s*=\s*["']([^"']+)["'][^>]*>`)
+)
+
+// escapeStringResource escapes the value for the text of an Android string resource.
+func escapeStringResource(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `&`, `&amp;`, `<`, `&lt;`, `'`, `\'`, `"`, `\"`, "\n", `\n`).Replace(value)
+	if strings.HasPrefix(escaped, "@") || strings.HasPrefix(escaped, "?") {
+		escaped = `\` + escaped
+	}
+	return escaped
+}
+
+// UpdateStringResources sets the text of the <string> resources with the given names, keeping the attributes
+// (like translatable="false"), the comments and the formatting of the file. Commented out resources are skipped.
+// It returns the updated content and the names of the updated resources.
+func UpdateStringResources(content string, values map[string]string) (string, []string) {
+	comments := xmlCommentRegexp.FindAllStringIndex(content, -1)
+	inComment := func(offset int) bool {
+		for _, c := range comments {
+			if offset >= c[0] && offset < c[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	tags := stringResourceTagRegexp.FindAllStringSubmatchIndex(content, -1)
+
+	var updated []string
+	for i := len(tags) - 1; i >= 0; i-- {
+		tag := tags[i]
+		if inComment(tag[0]) || strings.HasSuffix(content[tag[0]:tag[1]], "/>") {
+			continue
+		}
+
+		name := content[tag[2]:tag[3]]
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+
+		end := strings.Index(content[tag[1]:], "</string")
+		if end < 0 {
+			continue
+		}
+		content = content[:tag[1]] + escapeStringResource(value) + content[tag[1]+end:]
+		updated = append([]string{name}, updated...)
+	}
+	return content, updated
+}
+
+// findValuesResourceFiles returns the XML files of the res/values* directories of the module's source sets,
+// or of every source set if none is given.
+func findValuesResourceFiles(moduleDir string, sourceSets []string) ([]string, error) {
+	if len(sourceSets) == 0 {
+		sourceSets = []string{"*"}
+	}
+
+	var files []string
+	for _, sourceSet := range sourceSets {
+		matches, err := filepath.Glob(filepath.Join(moduleDir, "src", sourceSet, "res", "values*", "*.xml"))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				files = append(files, match)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// updateStringResources sets the configured <string> resources in the res/values* directories of the module's source sets.
+// The values are <name>=<template> items, the templates are expanded with the final versions.
+func updateStringResources(cfg config, res UpdateResult) error {
+	values, err := parseNamedValues("string resource", cfg.StringResources, versionPlaceholders(cfg, res))
+	if err != nil {
+		return err
+	}
+
+	files, err := findValuesResourceFiles(filepath.Dir(cfg.BuildGradlePth), cfg.StringResourceSourceSets)
+	if err != nil {
+		return err
+	}
+
+	found := map[string]bool{}
+	for _, pth := range files {
+		content, err := fileutil.ReadStringFromFile(pth)
+		if err != nil {
+			return err
+		}
+
+		updatedContent, updated := UpdateStringResources(content, values)
+		if len(updated) == 0 {
+			continue
+		}
+		if err := fileutil.WriteStringToFile(pth, updatedContent); err != nil {
+			return err
+		}
+		for _, name := range updated {
+			found[name] = true
+			log.Printf("%s: %s", pth, name)
+		}
+	}
+
+	for name := range values {
+		if !found[name] {
+			log.Warnf("No string resource named (%s) found", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateStringResources(t *testing.T) {
+	content := `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <!-- <string name="app_version">0.9.0</string> -->
+    <string name="app_name">Example</string>
+    <string name="app_version" translatable="false">1.2.0</string>
+    <string-array name="app_version_history">
+        <item>1.1.0</item>
+    </string-array>
+    <string name="about" translatable="false"
+        >Version 1.2.0</string>
+    <string name="empty"/>
+</resources>
+`
+
+	got, updated := UpdateStringResources(content, map[string]string{
+		"app_version":         "1.3.0",
+		"about":               `Version 1.3.0 "beta" & <more>`,
+		"app_version_history": "1.3.0",
+		"empty":               "1.3.0",
+	})
+	want := `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <!-- <string name="app_version">0.9.0</string> -->
+    <string name="app_name">Example</string>
+    <string name="app_version" translatable="false">1.3.0</string>
+    <string-array name="app_version_history">
+        <item>1.1.0</item>
+    </string-array>
+    <string name="about" translatable="false"
+        >Version 1.3.0 \"beta\" &amp; &lt;more></string>
+    <string name="empty"/>
+</resources>
+`
+	if got != want {
+		t.Errorf("UpdateStringResources() = %s, want %s", got, want)
+	}
+	if !reflect.DeepEqual(updated, []string{"app_version", "about"}) {
+		t.Errorf("UpdateStringResources() updated = %v", updated)
+	}
+}
+
+func Test_findValuesResourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, pth := range []string{
+		"src/main/res/values/strings.xml",
+		"src/main/res/values-de/strings.xml",
+		"src/main/res/layout/main.xml",
+		"src/paid/res/values/strings.xml",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(pth)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, pth), []byte("<resources/>"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := findValuesResourceFiles(dir, []string{"main"})
+	if err != nil {
+		t.Fatalf("findValuesResourceFiles() error = %s", err)
+	}
+	want := []string{
+		filepath.Join(dir, "src/main/res/values-de/strings.xml"),
+		filepath.Join(dir, "src/main/res/values/strings.xml"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findValuesResourceFiles() = %v, want %v", got, want)
+	}
+
+	all, err := findValuesResourceFiles(dir, nil)
+	if err != nil || len(all) != 3 {
+		t.Errorf("findValuesResourceFiles() every source set = %v, %v", all, err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{version: "1.2.3"},
+		{version: "1.2.3-rc.1"},
+		{version: "1.2.3-alpha.beta+build.42"},
+		{version: "0.0.0"},
+		{version: "1.2", wantErr: true},
+		{version: "01.2.3", wantErr: true},
+		{version: "1.2.3-01", wantErr: true},
+		{version: "v1.2.3", wantErr: true},
+		{version: "1.2.3-", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := ParseSemver(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSemver() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.String() != tt.version {
+				t.Errorf("ParseSemver().String() = %v, want %v", got, tt.version)
+			}
+		})
+	}
+}
+
+func TestSemver_Compare(t *testing.T) {
+	// ordered by precedence, as in the Semantic Versioning specification
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, b := mustParseSemver(t, ordered[i]), mustParseSemver(t, ordered[j])
+			if got, want := a.Compare(b), sign(i-j); got != want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+
+	if got := mustParseSemver(t, "1.0.0+build.1").Compare(mustParseSemver(t, "1.0.0+build.2")); got != 0 {
+		t.Errorf("build metadata should be ignored, got %d", got)
+	}
+}
+
+func mustParseSemver(t *testing.T, version string) Semver {
+	v, err := ParseSemver(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	// semverPrereleaseDigits is the number of low-order versionCode digits reserved for the prerelease:
+	// the stage digit followed by the 2 digit prerelease number.
+	semverPrereleaseDigits = 3
+	// semverReleaseStage is the stage digit of releases, higher than every prerelease stage.
+	semverReleaseStage = 9
+)
+
+// semverPrereleaseStages are the stage digits of the encodable prerelease labels, ordered the same way as their semver precedence.
+var semverPrereleaseStages = map[string]int{"alpha": 1, "beta": 2, "rc": 3}
+
+// SemverVersionCode encodes the semantic version into a versionCode which orders the same way as semver precedence:
+// major, minor (2 digits), patch (2 digits), then the prerelease stage (1 digit) and number (2 digits).
+// For example 1.3.0-alpha.2 is 10300102, 1.3.0-rc.3 is 10300303 and 1.3.0 is 10300900.
+// Only alpha, beta and rc prereleases, optionally followed by a number between 1 and 99, can be encoded.
+func SemverVersionCode(v Semver) (int, error) {
+	if v.Minor > 99 || v.Patch > 99 {
+		return 0, fmt.Errorf("version (%s) minor and patch must not exceed 99", v)
+	}
+
+	stage, number := semverReleaseStage, 0
+	if len(v.Prerelease) > 0 {
+		var ok bool
+		if stage, ok = semverPrereleaseStages[v.Prerelease[0]]; !ok {
+			return 0, fmt.Errorf("prerelease label (%s) of version (%s) can not be encoded, use alpha, beta or rc", v.Prerelease[0], v)
+		}
+
+		switch len(v.Prerelease) {
+		case 1:
+		case 2:
+			n, err := strconv.Atoi(v.Prerelease[1])
+			if err != nil || n < 1 || n > 99 {
+				return 0, fmt.Errorf("prerelease number (%s) of version (%s) can not be encoded, use a number between 1 and 99", v.Prerelease[1], v)
+			}
+			number = n
+		default:
+			return 0, fmt.Errorf("prerelease (%s) of version (%s) can not be encoded, use <label>.<number>", strings.Join(v.Prerelease, "."), v)
+		}
+	}
+
+	code := int64(v.Major)
+	for _, part := range []struct {
+		value  int
+		digits int64
+	}{
+		{v.Minor, 100},
+		{v.Patch, 100},
+		{stage*100 + number, 1000},
+	} {
+		code = code*part.digits + int64(part.value)
+	}
+	if code <= 0 || code > versionCodeMax {
+		return 0, fmt.Errorf("versionCode (%d) of version (%s) is out of range ]0..%d]", code, v, versionCodeMax)
+	}
+	return int(code), nil
+}
+
+// generateSemverVersionCode encodes the new versionName, or the current one if it is not updated, into a versionCode
+// which orders the same way as the semantic versions, prereleases included.
+func generateSemverVersionCode(cfg config, currentVersionName string) (int, error) {
+	versionName := cfg.NewVersionName
+	if versionName == "" {
+		if removeQuotationMarks(currentVersionName) == currentVersionName {
+			return 0, fmt.Errorf("current versionName (%s) is not a literal", currentVersionName)
+		}
+		versionName = currentVersionName
+	}
+	versionName = removeQuotationMarks(versionName)
+	log.Printf("versionName: %s", versionName)
+
+	version, err := ParseSemver(versionName)
+	if err != nil {
+		return 0, err
+	}
+	code, err := SemverVersionCode(version)
+	if err != nil {
+		return 0, err
+	}
+	if code+cfg.VersionCodeOffset > versionCodeMax {
+		return 0, fmt.Errorf("generated versionCode (%d) with offset (%d) exceeds %d", code, cfg.VersionCodeOffset, versionCodeMax)
+	}
+	return code, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSemverVersionCode(t *testing.T) {
+	// in semver precedence order
+	versions := []string{
+		"1.2.9",
+		"1.3.0-alpha",
+		"1.3.0-alpha.2",
+		"1.3.0-alpha.10",
+		"1.3.0-beta.1",
+		"1.3.0-rc.3",
+		"1.3.0",
+		"1.3.1-alpha.1",
+		"2.0.0-rc.1+build.5",
+		"2.0.0",
+	}
+	want := []int{10209900, 10300100, 10300102, 10300110, 10300201, 10300303, 10300900, 10301101, 20000301, 20000900}
+
+	var previous Semver
+	for i, version := range versions {
+		v, err := ParseSemver(version)
+		if err != nil {
+			t.Fatalf("ParseSemver(%s) error = %s", version, err)
+		}
+		got, err := SemverVersionCode(v)
+		if err != nil {
+			t.Fatalf("SemverVersionCode(%s) error = %s", version, err)
+		}
+		if got != want[i] {
+			t.Errorf("SemverVersionCode(%s) = %d, want %d", version, got, want[i])
+		}
+		if i > 0 && v.Compare(previous) <= 0 {
+			t.Fatalf("test versions are not in precedence order: %s, %s", previous, v)
+		}
+		previous = v
+	}
+}
+
+func TestSemverVersionCode_Unencodable(t *testing.T) {
+	for _, version := range []string{
+		"1.3.0-dev.1",
+		"1.3.0-RC.1",
+		"1.3.0-alpha.0",
+		"1.3.0-beta.100",
+		"1.3.0-beta.x",
+		"1.3.0-rc.1.2",
+		"1.100.0",
+		"1.3.100",
+		"211.0.0",
+	} {
+		v, err := ParseSemver(version)
+		if err != nil {
+			t.Fatalf("ParseSemver(%s) error = %s", version, err)
+		}
+		if got, err := SemverVersionCode(v); err == nil {
+			t.Errorf("SemverVersionCode(%s) = %d, expected error", version, got)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+)
+
+var jsonPathSegmentRegexp = regexp.MustCompile(`^(?:\.([^.\[\]]+)|\[(\d+)\])`)
+
+// IsVersionSourceReference reports whether the value is a file:, json: or properties: source reference.
+func IsVersionSourceReference(value string) bool {
+	for _, prefix := range []string{"file:", "json:", "properties:"} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadVersionSource reads the value of a source reference:
+//   - file:<path>: the trimmed content of the file, like a VERSION file.
+//   - json:<path>#<path expression>: a string or number of a JSON file, like json:package.json#.version or json:version.json#.android.code.
+//   - properties:<path>#<key>: the value of a key of a Java properties file, like properties:version.properties#VERSION_CODE.
+func ReadVersionSource(reference string) (string, error) {
+	kind, location := reference, ""
+	if i := strings.Index(reference, ":"); i >= 0 {
+		kind, location = reference[:i], reference[i+1:]
+	}
+	pth, key := location, ""
+	if i := strings.LastIndex(location, "#"); i >= 0 {
+		pth, key = location[:i], location[i+1:]
+	}
+
+	if kind != "file" && key == "" {
+		return "", fmt.Errorf("%s source (%s) requires a #key, like %s:%s#version", kind, reference, kind, pth)
+	}
+	if kind == "file" {
+		pth = location
+	}
+
+	content, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return "", fmt.Errorf("failed to read source file (%s): %s", pth, err)
+	}
+
+	var value string
+	switch kind {
+	case "file":
+		value = strings.TrimSpace(string(content))
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("source file (%s) has more than one line", pth)
+		}
+	case "json":
+		if value, err = jsonSourceValue(content, key); err != nil {
+			return "", fmt.Errorf("source file (%s): %s", pth, err)
+		}
+	case "properties":
+		properties, err := parseProperties(content)
+		if err != nil {
+			return "", fmt.Errorf("source file (%s): %s", pth, err)
+		}
+		var ok bool
+		if value, ok = properties[key]; !ok {
+			return "", fmt.Errorf("source file (%s) has no property (%s)", pth, key)
+		}
+	default:
+		return "", fmt.Errorf("unknown source (%s), expected file:, json: or properties:", reference)
+	}
+
+	if value == "" {
+		return "", fmt.Errorf("source (%s) is empty", reference)
+	}
+	return value, nil
+}
+
+// jsonSourceValue returns the string or number at the path expression, like .android.code or .versions[0].
+func jsonSourceValue(content []byte, path string) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("invalid JSON: %s", err)
+	}
+
+	for rest := path; rest != ""; {
+		match := jsonPathSegmentRegexp.FindStringSubmatch(rest)
+		if match == nil {
+			return "", fmt.Errorf("invalid path expression (%s), expected segments like .key or [0]", path)
+		}
+		rest = rest[len(match[0]):]
+
+		if match[1] != "" {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("path (%s): %s is not an object key", path, match[0])
+			}
+			if value, ok = object[match[1]]; !ok {
+				return "", fmt.Errorf("path (%s): key %s not found", path, match[1])
+			}
+		} else {
+			array, ok := value.([]interface{})
+			index, _ := strconv.Atoi(match[2])
+			if !ok || index >= len(array) {
+				return "", fmt.Errorf("path (%s): index %s not found", path, match[0])
+			}
+			value = array[index]
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("path (%s) is not a string or number", path)
+	}
+}
+
+// parseProperties parses the key=value, key: value and key value lines of a Java properties file.
+// Comments (# and !) are skipped, lines ending with \ are continued.
+func parseProperties(content []byte) (map[string]string, error) {
+	properties := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	logical := ""
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if logical == "" && (line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!")) {
+			continue
+		}
+		if strings.HasSuffix(line, `\`) && !strings.HasSuffix(line, `\\`) {
+			logical += strings.TrimSuffix(line, `\`)
+			continue
+		}
+		logical += line
+
+		end := strings.IndexAny(logical, "=: \t")
+		key, value := logical, ""
+		if end >= 0 {
+			key = logical[:end]
+			value = strings.TrimLeft(logical[end:], " \t")
+			if strings.HasPrefix(value, "=") || strings.HasPrefix(value, ":") {
+				value = strings.TrimLeft(value[1:], " \t")
+			}
+		}
+		properties[key] = strings.TrimSpace(value)
+		logical = ""
+	}
+	return properties, scanner.Err()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadVersionSource(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"VERSION":            "1.3.0\n",
+		"VERSION_MULTILINE":  "1.3.0\n1.2.0\n",
+		"version.json":       `{"android": {"code": 2042, "name": "1.3.0"}, "builds": [{"code": 7}]}`,
+		"package.json":       `{"name": "web", "version": "1.3.0-rc.1"}`,
+		"version.properties": "# versions\nVERSION_NAME = 1.3.0\nVERSION_CODE:2042\nLONG=a\\\n  b\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		reference string
+		want      string
+		wantErr   bool
+	}{
+		{reference: "file:" + filepath.Join(dir, "VERSION"), want: "1.3.0"},
+		{reference: "file:" + filepath.Join(dir, "VERSION_MULTILINE"), wantErr: true},
+		{reference: "json:" + filepath.Join(dir, "version.json") + "#.android.code", want: "2042"},
+		{reference: "json:" + filepath.Join(dir, "version.json") + "#.builds[0].code", want: "7"},
+		{reference: "json:" + filepath.Join(dir, "package.json") + "#.version", want: "1.3.0-rc.1"},
+		{reference: "json:" + filepath.Join(dir, "version.json") + "#.android", wantErr: true},
+		{reference: "json:" + filepath.Join(dir, "version.json") + "#.ios.code", wantErr: true},
+		{reference: "json:" + filepath.Join(dir, "version.json"), wantErr: true},
+		{reference: "properties:" + filepath.Join(dir, "version.properties") + "#VERSION_NAME", want: "1.3.0"},
+		{reference: "properties:" + filepath.Join(dir, "version.properties") + "#VERSION_CODE", want: "2042"},
+		{reference: "properties:" + filepath.Join(dir, "version.properties") + "#LONG", want: "ab"},
+		{reference: "properties:" + filepath.Join(dir, "version.properties") + "#MISSING", wantErr: true},
+		{reference: "file:" + filepath.Join(dir, "MISSING"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			got, err := ReadVersionSource(tt.reference)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadVersionSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ReadVersionSource() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsVersionSourceReference(t *testing.T) {
+	for value, want := range map[string]bool{
+		"file:VERSION":                      true,
+		"json:version.json#.android.code":   true,
+		"properties:v.properties#CODE":      true,
+		"1.3.0":                             false,
+		`"${versionMajor}.${versionMinor}"`: false,
+	} {
+		if got := IsVersionSourceReference(value); got != want {
+			t.Errorf("IsVersionSourceReference(%s) = %v, want %v", value, got, want)
+		}
+	}
+}
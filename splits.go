@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+var (
+	splitEnableRegexp     = regexp.MustCompile(`(?m)^\s*(?:enable|isEnable)\s*(?:=\s*)?true\b`)
+	splitIncludeRegexp    = regexp.MustCompile(`(?m)^\s*include\s*\(?([^)\n]*)\)?`)
+	splitMultiplierRegexp = regexp.MustCompile(`\.get\(\s*\w+(?:\.\w+\(\))?\s*\)!*\s*\*\s*(\d+)\s*\+\s*(?:\w+\.)*versionCode`)
+	quotedStringRegexp    = regexp.MustCompile(`["']([^"']+)["']`)
+	minSdkRegexp          = regexp.MustCompile(`(?m)(?:^|[\s{;])minSdk(?:Version)?\s*(?:=\s*|\(\s*)?(\d+)`)
+	codeMapEntryRegexp    = regexp.MustCompile(`["']([\w-]+)["']\s*(?::|to)\s*(\d+)`)
+
+	// defaultABICodes are the ABI codes of the Android documentation's split versionCode example.
+	defaultABICodes = map[string]int{"armeabi-v7a": 1, "arm64-v8a": 2, "x86": 3, "x86_64": 4}
+	// defaultDensityCodes are ordered by density, so that higher densities get higher versionCodes.
+	defaultDensityCodes = map[string]int{"ldpi": 1, "mdpi": 2, "hdpi": 3, "xhdpi": 4, "xxhdpi": 5, "xxxhdpi": 6}
+)
+
+// Splits is the ABI and density split configuration of a module.
+type Splits struct {
+	ABIs        []string
+	Densities   []string
+	ABICodes    map[string]int
+	DensityCode map[string]int
+	// Multiplier is the split code multiplier of the versionCode formula, 0 if not found.
+	Multiplier int
+	MinSdk     int
+}
+
+// ParseSplits reads the enabled splits { abi { } density { } } blocks, the ABI and density code mapping tables
+// (like def abiCodes = ["armeabi-v7a": 1]) and the versionCode formula multiplier from build.gradle content.
+func ParseSplits(content string) Splits {
+	s := Splits{ABICodes: map[string]int{}, DensityCode: map[string]int{}}
+
+	if block, ok := findGradleBlock(content, "android", "splits", "abi"); ok && splitEnableRegexp.MatchString(block.Body) {
+		s.ABIs = splitIncludes(block.Body)
+	}
+	if block, ok := findGradleBlock(content, "android", "splits", "density"); ok && splitEnableRegexp.MatchString(block.Body) {
+		s.Densities = splitIncludes(block.Body)
+	}
+
+	for _, entry := range codeMapEntryRegexp.FindAllStringSubmatch(content, -1) {
+		code, err := strconv.Atoi(entry[2])
+		if err != nil {
+			continue
+		}
+		if _, ok := defaultABICodes[entry[1]]; ok {
+			s.ABICodes[entry[1]] = code
+		} else if _, ok := defaultDensityCodes[entry[1]]; ok {
+			s.DensityCode[entry[1]] = code
+		}
+	}
+
+	if match := splitMultiplierRegexp.FindStringSubmatch(content); match != nil {
+		s.Multiplier, _ = strconv.Atoi(match[1])
+	}
+	if match := minSdkRegexp.FindStringSubmatch(content); match != nil {
+		s.MinSdk, _ = strconv.Atoi(match[1])
+	}
+	return s
+}
+
+func splitIncludes(body string) []string {
+	var includes []string
+	for _, match := range splitIncludeRegexp.FindAllStringSubmatch(body, -1) {
+		for _, value := range quotedStringRegexp.FindAllStringSubmatch(match[1], -1) {
+			includes = append(includes, value[1])
+		}
+	}
+	return includes
+}
+
+// SplitVersionCode is the versionCode of an ABI and/or density split output.
+type SplitVersionCode struct {
+	Name        string
+	ABI         string
+	Density     string
+	VersionCode int
+}
+
+// OutputKey returns the env var key the versionCode is exported with, for example ANDROID_VERSION_CODE_ARM64_V8A.
+func (c SplitVersionCode) OutputKey() string {
+	return "ANDROID_VERSION_CODE_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(c.Name))
+}
+
+// SplitVersionCodes computes the versionCode of every split output with the given template:
+//   - abi_multiplier: split code * multiplier + versionCode, where the split code is the ABI code,
+//     the density code or ABI code * 10 + density code if both splits are enabled.
+//   - min_sdk_density_abi: minSdk (2 digits), density code (1 digit), ABI code (1 digit) and versionCode (3 digits).
+//     It fails if the minSdk of the file is not a literal.
+func (s Splits) SplitVersionCodes(template string, versionCode, multiplier int) ([]SplitVersionCode, error) {
+	abis, densities := []string{""}, []string{""}
+	if len(s.ABIs) > 0 {
+		abis = s.ABIs
+	}
+	if len(s.Densities) > 0 {
+		densities = s.Densities
+	}
+
+	var codes []SplitVersionCode
+	for _, abi := range abis {
+		for _, density := range densities {
+			if abi == "" && density == "" {
+				continue
+			}
+
+			abiCode, err := splitCode(abi, s.ABICodes, defaultABICodes)
+			if err != nil {
+				return nil, err
+			}
+			densityCode, err := splitCode(density, s.DensityCode, defaultDensityCodes)
+			if err != nil {
+				return nil, err
+			}
+
+			c := SplitVersionCode{Name: strings.Trim(abi+"_"+density, "_"), ABI: abi, Density: density}
+			switch template {
+			case "abi_multiplier":
+				code := abiCode
+				if abi == "" {
+					code = densityCode
+				} else if density != "" {
+					code = abiCode*10 + densityCode
+				}
+				c.VersionCode = code*multiplier + versionCode
+			case "min_sdk_density_abi":
+				if s.MinSdk == 0 {
+					return nil, fmt.Errorf("minSdk not found or not a literal, it is required by the %s template", template)
+				}
+				if versionCode >= 1000 || s.MinSdk >= 100 || densityCode >= 10 || abiCode >= 10 {
+					return nil, fmt.Errorf("versionCode (%d), minSdk (%d), density code (%d) and ABI code (%d) do not fit the %s template digits", versionCode, s.MinSdk, densityCode, abiCode, template)
+				}
+				c.VersionCode = s.MinSdk*100000 + densityCode*10000 + abiCode*1000 + versionCode
+			default:
+				return nil, fmt.Errorf("unknown split versionCode template (%s)", template)
+			}
+			codes = append(codes, c)
+		}
+	}
+	return codes, nil
+}
+
+func splitCode(name string, codes, defaults map[string]int) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+	if code, ok := codes[name]; ok {
+		return code, nil
+	}
+	if code, ok := defaults[name]; ok {
+		return code, nil
+	}
+	return 0, fmt.Errorf("no split code for (%s)", name)
+}
+
+// ValidateSplitVersionCodes checks that every split versionCode is in range and unique.
+func ValidateSplitVersionCodes(codes []SplitVersionCode) []string {
+	var named []NamedVersionCode
+	for _, c := range codes {
+		named = append(named, NamedVersionCode{Name: c.Name, VersionCode: c.VersionCode})
+	}
+	return ValidateVersionCodes(named)
+}
+
+// computeSplitVersionCodes computes the versionCode of every ABI and density split output of the updated build.gradle.
+// It returns the outputs to export, one per split and a JSON map of all of them.
+func computeSplitVersionCodes(cfg config, res UpdateResult) (map[string]string, error) {
+	versionCode, err := strconv.Atoi(res.FinalVersionCode)
+	if err != nil {
+		return nil, fmt.Errorf("final versionCode (%s) is not an integer", res.FinalVersionCode)
+	}
+
+	splits := ParseSplits(res.NewContent)
+	if len(splits.ABIs) == 0 && len(splits.Densities) == 0 {
+		return nil, fmt.Errorf("no enabled ABI or density splits found in: %s", cfg.BuildGradlePth)
+	}
+
+	multiplier := cfg.SplitVersionCodeMultiplier
+	if multiplier == 0 {
+		multiplier = splits.Multiplier
+	}
+	if multiplier == 0 {
+		multiplier = 1000
+	}
+
+	codes, err := splits.SplitVersionCodes(cfg.SplitVersionCodes, versionCode, multiplier)
+	if err != nil {
+		return nil, err
+	}
+	if violations := ValidateSplitVersionCodes(codes); len(violations) > 0 {
+		return nil, fmt.Errorf("invalid split versionCodes:\n- %s", strings.Join(violations, "\n- "))
+	}
+
+	outputs := map[string]string{}
+	codeMap := map[string]int{}
+	for _, c := range codes {
+		log.Printf("%s: %d", c.Name, c.VersionCode)
+		outputs[c.OutputKey()] = strconv.Itoa(c.VersionCode)
+		codeMap[c.Name] = c.VersionCode
+	}
+
+	out, err := json.Marshal(codeMap)
+	if err != nil {
+		return nil, err
+	}
+	outputs["ANDROID_SPLIT_VERSION_CODES"] = string(out)
+	return outputs, nil
+}
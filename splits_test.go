@@ -0,0 +1,139 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testSplitsBuildGradle = `android {
+    defaultConfig {
+        minSdkVersion 21
+        versionCode 7
+    }
+    splits {
+        abi {
+            enable true
+            reset()
+            include "x86", "armeabi-v7a", "arm64-v8a", "x86_64"
+            universalApk false
+        }
+        density {
+            enable false
+            include "mdpi", "hdpi"
+        }
+    }
+}
+
+def versionCodes = ["armeabi-v7a": 1, "x86": 2, "arm64-v8a": 3, "x86_64": 4]
+android.applicationVariants.all { variant ->
+    variant.outputs.each { output ->
+        def abi = output.getFilter(com.android.build.OutputFile.ABI)
+        output.versionCodeOverride = versionCodes.get(abi) * 1000 + defaultConfig.versionCode
+    }
+}`
+
+func TestParseSplits(t *testing.T) {
+	got := ParseSplits(testSplitsBuildGradle)
+	want := Splits{
+		ABIs:        []string{"x86", "armeabi-v7a", "arm64-v8a", "x86_64"},
+		ABICodes:    map[string]int{"armeabi-v7a": 1, "x86": 2, "arm64-v8a": 3, "x86_64": 4},
+		DensityCode: map[string]int{},
+		Multiplier:  1000,
+		MinSdk:      21,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSplits() = %+v, want %+v", got, want)
+	}
+
+	kotlin := ParseSplits(`android {
+    defaultConfig { minSdk = 24 }
+    splits {
+        density {
+            isEnable = true
+            include("hdpi", "xxhdpi")
+        }
+    }
+}`)
+	if !reflect.DeepEqual(kotlin.Densities, []string{"hdpi", "xxhdpi"}) || kotlin.MinSdk != 24 {
+		t.Errorf("ParseSplits() Kotlin DSL = %+v", kotlin)
+	}
+}
+
+func TestSplits_SplitVersionCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		splits   Splits
+		template string
+		want     map[string]int
+		wantErr  bool
+	}{
+		{
+			name:     "ABI multiplier with the file's mapping",
+			splits:   ParseSplits(testSplitsBuildGradle),
+			template: "abi_multiplier",
+			want:     map[string]int{"x86": 2555, "armeabi-v7a": 1555, "arm64-v8a": 3555, "x86_64": 4555},
+		},
+		{
+			name:     "ABI and density with default codes",
+			splits:   Splits{ABIs: []string{"arm64-v8a"}, Densities: []string{"hdpi", "xhdpi"}},
+			template: "abi_multiplier",
+			want:     map[string]int{"arm64-v8a_hdpi": 23555, "arm64-v8a_xhdpi": 24555},
+		},
+		{
+			name:     "MinSdk, density and ABI digits",
+			splits:   Splits{ABIs: []string{"armeabi-v7a", "x86"}, Densities: []string{"hdpi"}, MinSdk: 21},
+			template: "min_sdk_density_abi",
+			want:     map[string]int{"armeabi-v7a_hdpi": 2131555, "x86_hdpi": 2133555},
+		},
+		{
+			name:     "MinSdk is not a literal",
+			splits:   ParseSplits("android {\n    defaultConfig {\n        minSdk libs.versions.minSdk.get().toInt()\n    }\n    splits {\n        abi {\n            enable true\n            include \"x86\"\n        }\n    }\n}"),
+			template: "min_sdk_density_abi",
+			wantErr:  true,
+		},
+		{
+			name:     "Unknown ABI",
+			splits:   Splits{ABIs: []string{"mips"}},
+			template: "abi_multiplier",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes, err := tt.splits.SplitVersionCodes(tt.template, 555, 1000)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Splits.SplitVersionCodes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got := map[string]int{}
+			for _, c := range codes {
+				got[c.Name] = c.VersionCode
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Splits.SplitVersionCodes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSplitVersionCodes(t *testing.T) {
+	codes := []SplitVersionCode{
+		{Name: "x86", VersionCode: 2555},
+		{Name: "armeabi-v7a", VersionCode: 2555},
+		{Name: "arm64-v8a", VersionCode: 2100000001},
+	}
+	want := []string{
+		"arm64-v8a: versionCode (2100000001) is out of range ]0..2100000000]",
+		"x86, armeabi-v7a have the same versionCode (2555)",
+	}
+	if got := ValidateSplitVersionCodes(codes); !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateSplitVersionCodes() = %v, want %v", got, want)
+	}
+
+	if got := (SplitVersionCode{Name: "arm64-v8a"}).OutputKey(); got != "ANDROID_VERSION_CODE_ARM64_V8A" {
+		t.Errorf("SplitVersionCode.OutputKey() = %v", got)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// StoreProfile describes the version constraints of an app store.
+type StoreProfile struct {
+	Name string
+	// MaxVersionCode is the greatest versionCode the store accepts.
+	MaxVersionCode int
+	// MaxVersionNameLength is the greatest versionName length in characters, 0 means unlimited.
+	MaxVersionNameLength int
+	// VersionNamePattern is the pattern the versionName has to match, nil means any.
+	VersionNamePattern *regexp.Regexp
+	// IncreasingVersionCode requires the versionCode to be greater than the previous one.
+	IncreasingVersionCode bool
+}
+
+// storeProfiles are the built-in store profiles by name.
+var storeProfiles = map[string]StoreProfile{
+	"google_play": {
+		Name:                  "google_play",
+		MaxVersionCode:        versionCodeMax,
+		IncreasingVersionCode: true,
+	},
+	"amazon_appstore": {
+		Name:                  "amazon_appstore",
+		MaxVersionCode:        2147483647,
+		IncreasingVersionCode: true,
+	},
+	"huawei_appgallery": {
+		Name:                  "huawei_appgallery",
+		MaxVersionCode:        2147483647,
+		MaxVersionNameLength:  32,
+		VersionNamePattern:    regexp.MustCompile(`^[0-9A-Za-z._-]+$`),
+		IncreasingVersionCode: true,
+	},
+	"samsung_galaxy_store": {
+		Name:                  "samsung_galaxy_store",
+		MaxVersionCode:        2147483647,
+		MaxVersionNameLength:  50,
+		VersionNamePattern:    regexp.MustCompile(`^\d+(\.\d+)*`),
+		IncreasingVersionCode: true,
+	},
+}
+
+// storeProfileNames returns the names of the built-in store profiles.
+func storeProfileNames() []string {
+	var names []string
+	for name := range storeProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks the final versionCode and versionName against the profile
+// and returns every violation. The previous versionCode is the one in the file before the update.
+// Empty values are not checked.
+func (p StoreProfile) Validate(previousVersionCode, versionCode, versionName string) []string {
+	var violations []string
+
+	if code, err := strconv.Atoi(versionCode); versionCode != "" && err != nil {
+		violations = append(violations, fmt.Sprintf("%s: versionCode (%s) is not an integer", p.Name, versionCode))
+	} else if versionCode != "" {
+		if code <= 0 || code > p.MaxVersionCode {
+			violations = append(violations, fmt.Sprintf("%s: versionCode (%d) is out of range ]0..%d]", p.Name, code, p.MaxVersionCode))
+		}
+		if previous, err := strconv.Atoi(previousVersionCode); err == nil && p.IncreasingVersionCode && code <= previous {
+			violations = append(violations, fmt.Sprintf("%s: versionCode (%d) is not greater than the previous versionCode (%d)", p.Name, code, previous))
+		}
+	}
+
+	if length := utf8.RuneCountInString(versionName); p.MaxVersionNameLength > 0 && length > p.MaxVersionNameLength {
+		violations = append(violations, fmt.Sprintf("%s: versionName (%s) is %d characters long, at most %d allowed", p.Name, versionName, length, p.MaxVersionNameLength))
+	}
+	if p.VersionNamePattern != nil && versionName != "" && !p.VersionNamePattern.MatchString(versionName) {
+		violations = append(violations, fmt.Sprintf("%s: versionName (%s) does not match pattern (%s)", p.Name, versionName, p.VersionNamePattern))
+	}
+	return violations
+}
+
+// validateVersionCodeRange checks the final versionCode, offset included, against the Google Play limit,
+// which is the lowest limit of the supported stores.
+func validateVersionCodeRange(versionCode string) error {
+	code, err := strconv.Atoi(versionCode)
+	if err != nil {
+		return fmt.Errorf("versionCode (%s) is not an integer", versionCode)
+	}
+	if code <= 0 || code > versionCodeMax {
+		return fmt.Errorf("versionCode (%d) is out of range ]0..%d]", code, versionCodeMax)
+	}
+	return nil
+}
+
+// validateStoreProfiles checks the final versions against the named store profiles.
+// Values left unchanged are only checked if they are literals, as expressions can not be evaluated.
+func validateStoreProfiles(names []string, current, res UpdateResult) error {
+	previousVersionCode := current.FinalVersionCode
+	versionCode := res.FinalVersionCode
+	if res.UpdatedVersionCodes == 0 {
+		previousVersionCode = ""
+		if _, err := strconv.Atoi(versionCode); err != nil {
+			log.Warnf("versionCode (%s) is not a literal, skipping its validation", versionCode)
+			versionCode = ""
+		}
+	}
+
+	versionName := removeQuotationMarks(res.FinalVersionName)
+	if res.UpdatedVersionNames == 0 && removeQuotationMarks(res.FinalVersionName) == res.FinalVersionName {
+		log.Warnf("versionName (%s) is not a literal, skipping its validation", res.FinalVersionName)
+		versionName = ""
+	}
+
+	var violations []string
+	for _, name := range names {
+		profile, ok := storeProfiles[name]
+		if !ok {
+			return fmt.Errorf("unknown store profile (%s), available profiles: %s", name, strings.Join(storeProfileNames(), ", "))
+		}
+
+		for _, violation := range profile.Validate(previousVersionCode, versionCode, versionName) {
+			violations = append(violations, "- "+violation)
+		}
+	}
+	if len(violations) > 0 {
+		return errors.New(strings.Join(violations, "\n"))
+	}
+	return nil
+}
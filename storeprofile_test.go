@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStoreProfile_Validate(t *testing.T) {
+	tests := []struct {
+		name                string
+		profile             string
+		previousVersionCode string
+		versionCode         string
+		versionName         string
+		want                []string
+	}{
+		{
+			name:                "Valid Google Play version",
+			profile:             "google_play",
+			previousVersionCode: "41",
+			versionCode:         "42",
+			versionName:         "1.2.0",
+		},
+		{
+			name:        "Google Play ceiling",
+			profile:     "google_play",
+			versionCode: "2100000001",
+			versionName: "1.2.0",
+			want:        []string{"google_play: versionCode (2100000001) is out of range ]0..2100000000]"},
+		},
+		{
+			name:        "Amazon Appstore allows greater versionCodes",
+			profile:     "amazon_appstore",
+			versionCode: "2100000001",
+			versionName: "1.2.0",
+		},
+		{
+			name:                "Previous versionCode is not an integer",
+			profile:             "google_play",
+			previousVersionCode: "rootProject.ext.versionCode",
+			versionCode:         "1",
+			versionName:         "1.2.0",
+		},
+		{
+			name:                "Every violation is reported",
+			profile:             "huawei_appgallery",
+			previousVersionCode: "42",
+			versionCode:         "42",
+			versionName:         "1.2.0 " + strings.Repeat("beta", 8),
+			want: []string{
+				"huawei_appgallery: versionCode (42) is not greater than the previous versionCode (42)",
+				"huawei_appgallery: versionName (1.2.0 betabetabetabetabetabetabetabeta) is 38 characters long, at most 32 allowed",
+				"huawei_appgallery: versionName (1.2.0 betabetabetabetabetabetabetabeta) does not match pattern (^[0-9A-Za-z._-]+$)",
+			},
+		},
+		{
+			name:        "Not an integer versionCode",
+			profile:     "samsung_galaxy_store",
+			versionCode: "abc",
+			versionName: "1.2.0",
+			want:        []string{"samsung_galaxy_store: versionCode (abc) is not an integer"},
+		},
+		{
+			name:    "Empty values are not checked",
+			profile: "samsung_galaxy_store",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := storeProfiles[tt.profile].Validate(tt.previousVersionCode, tt.versionCode, tt.versionName)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StoreProfile.Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateVersionCodeRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		versionCode string
+		wantErr     bool
+	}{
+		{name: "Valid", versionCode: "42"},
+		{name: "Google Play limit", versionCode: "2100000000"},
+		{name: "Over the Google Play limit", versionCode: "2100000001", wantErr: true},
+		{name: "Zero", versionCode: "0", wantErr: true},
+		{name: "Not an integer", versionCode: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateVersionCodeRange(tt.versionCode); (err != nil) != tt.wantErr {
+				t.Errorf("validateVersionCodeRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
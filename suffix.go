@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// SuffixEdit sets the versionNameSuffix or applicationIdSuffix of a build type or product flavor.
+type SuffixEdit struct {
+	// Target is the name of the build type or product flavor.
+	Target   string
+	Property string
+	Value    string
+}
+
+// ParseSuffixEdits parses <build type or flavor>=<suffix> items, like qa=-qa.{build}.
+func ParseSuffixEdits(property string, items []string) ([]SuffixEdit, error) {
+	var edits []SuffixEdit
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid %s (%s), expected <build type or flavor>=<suffix>", property, item)
+		}
+		edits = append(edits, SuffixEdit{Target: strings.TrimSpace(parts[0]), Property: property, Value: strings.TrimSpace(parts[1])})
+	}
+	return edits, nil
+}
+
+// findVariantConfigBlock returns the block of the named build type or product flavor.
+func findVariantConfigBlock(content, name string) (GradleBlock, error) {
+	var found []GradleBlock
+	for _, parent := range []string{"buildTypes", "productFlavors"} {
+		for _, block := range findGradleBlocks(content, "android", parent) {
+			for _, b := range gradleBlocks(block.Body) {
+				if b.Name == name {
+					b.Start += block.Start
+					b.End += block.Start
+					found = append(found, b)
+				}
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return GradleBlock{}, fmt.Errorf("no build type or product flavor named (%s)", name)
+	case 1:
+		return found[0], nil
+	default:
+		return GradleBlock{}, fmt.Errorf("more than one build type or product flavor named (%s)", name)
+	}
+}
+
+// ApplySuffixEdit sets the suffix in the build type or product flavor block of the content.
+// The value is used as is if it is quoted, otherwise its {name} placeholders are expanded and it is quoted,
+// the same way the versionName template is.
+func ApplySuffixEdit(content string, edit SuffixEdit, values map[string]string, kotlin bool) (string, string, error) {
+	block, err := findVariantConfigBlock(content, edit.Target)
+	if err != nil {
+		return "", "", err
+	}
+
+	value := edit.Value
+	if _, added := quoteGradleString(value); added {
+		if value, err = versionNamePlaceholders.Expand(value, placeholderValues(values)); err != nil {
+			return "", "", fmt.Errorf("%s of %s (%s): %s", edit.Property, edit.Target, edit.Value, err)
+		}
+		value, _ = quoteGradleString(value)
+	}
+
+	return setGradleProperty(content, block, edit.Property, value, kotlin), value, nil
+}
+
+// applySuffixEdits sets the configured suffixes of the build types and flavors in the updated build.gradle.
+// It returns the updated content and the final versionName of every variant as a JSON map.
+func applySuffixEdits(cfg config, res UpdateResult) (string, string, error) {
+	nameSuffixes, err := ParseSuffixEdits("versionNameSuffix", cfg.VersionNameSuffixes)
+	if err != nil {
+		return "", "", err
+	}
+	idSuffixes, err := ParseSuffixEdits("applicationIdSuffix", cfg.ApplicationIDSuffixes)
+	if err != nil {
+		return "", "", err
+	}
+
+	values := versionPlaceholders(cfg, res)
+	kotlin := strings.HasSuffix(cfg.BuildGradlePth, ".kts")
+
+	content := res.NewContent
+	for _, edit := range append(nameSuffixes, idSuffixes...) {
+		var value string
+		if content, value, err = ApplySuffixEdit(content, edit, values, kotlin); err != nil {
+			return "", "", err
+		}
+		log.Printf("%s: %s %s", edit.Target, edit.Property, value)
+	}
+
+	versions, err := ResolveVariantVersions(content)
+	if err != nil {
+		return "", "", err
+	}
+	names := map[string]string{}
+	for _, v := range versions {
+		names[v.Variant] = v.VersionName
+		if sliceContains(v.Unresolved, "versionName") {
+			log.Warnf("%s: versionName depends on expressions which can not be evaluated", v.Variant)
+		} else {
+			log.Printf("%s: %s", v.Variant, v.VersionName)
+		}
+	}
+
+	out, err := json.Marshal(names)
+	if err != nil {
+		return "", "", err
+	}
+	return content, string(out), nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplySuffixEdit(t *testing.T) {
+	groovy := `android {
+    buildTypes {
+        release {
+            minifyEnabled true
+        }
+        qa {
+            versionNameSuffix "-qa"
+        }
+    }
+    productFlavors {
+        free { dimension "tier" }
+        paid {}
+    }
+}`
+	values := map[string]string{"build": "42"}
+
+	tests := []struct {
+		name    string
+		content string
+		edit    SuffixEdit
+		kotlin  bool
+		want    string
+	}{
+		{
+			name:    "Replace existing suffix",
+			content: groovy,
+			edit:    SuffixEdit{Target: "qa", Property: "versionNameSuffix", Value: "-qa.{build}"},
+			want:    "        qa {\n            versionNameSuffix \"-qa.42\"\n        }",
+		},
+		{
+			name:    "Add suffix to build type",
+			content: groovy,
+			edit:    SuffixEdit{Target: "release", Property: "applicationIdSuffix", Value: ".release"},
+			want:    "        release {\n            applicationIdSuffix \".release\"\n            minifyEnabled true\n        }",
+		},
+		{
+			name:    "Add suffix to single line flavor",
+			content: groovy,
+			edit:    SuffixEdit{Target: "free", Property: "versionNameSuffix", Value: "-free"},
+			want:    "        free {\n            versionNameSuffix \"-free\"\n            dimension \"tier\" }",
+		},
+		{
+			name:    "Add suffix to empty flavor",
+			content: groovy,
+			edit:    SuffixEdit{Target: "paid", Property: "versionNameSuffix", Value: `"-paid.${build}"`},
+			want:    "        paid {\n            versionNameSuffix \"-paid.${build}\"\n        }",
+		},
+		{
+			name:    "Kotlin DSL",
+			content: "android {\n\tbuildTypes {\n\t\tcreate(\"qa\") {\n\t\t\tisDebuggable = true\n\t\t}\n\t}\n}",
+			edit:    SuffixEdit{Target: "qa", Property: "applicationIdSuffix", Value: ".qa"},
+			kotlin:  true,
+			want:    "\t\tcreate(\"qa\") {\n\t\t\tapplicationIdSuffix = \".qa\"\n\t\t\tisDebuggable = true\n\t\t}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := ApplySuffixEdit(tt.content, tt.edit, values, tt.kotlin)
+			if err != nil {
+				t.Fatalf("ApplySuffixEdit() error = %s", err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("ApplySuffixEdit() = %s, want to contain:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := ApplySuffixEdit(groovy, SuffixEdit{Target: "staging", Property: "versionNameSuffix", Value: "-staging"}, values, false); err == nil {
+		t.Errorf("ApplySuffixEdit() expected error for unknown build type")
+	}
+}
+
+func TestParseSuffixEdits(t *testing.T) {
+	edits, err := ParseSuffixEdits("versionNameSuffix", []string{"qa=-qa.{build}", " paid = -paid "})
+	if err != nil {
+		t.Fatalf("ParseSuffixEdits() error = %s", err)
+	}
+	if len(edits) != 2 || edits[1] != (SuffixEdit{Target: "paid", Property: "versionNameSuffix", Value: "-paid"}) {
+		t.Errorf("ParseSuffixEdits() = %+v", edits)
+	}
+
+	if _, err := ParseSuffixEdits("versionNameSuffix", []string{"-qa"}); err == nil {
+		t.Errorf("ParseSuffixEdits() expected error")
+	}
+}
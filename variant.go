@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// VariantVersion is the effective version of a build variant, as Gradle merges it from defaultConfig,
+// the product flavors and the build type.
+type VariantVersion struct {
+	Variant       string `json:"variant"`
+	VersionCode   string `json:"version_code"`
+	VersionName   string `json:"version_name"`
+	ApplicationID string `json:"application_id"`
+	// Unresolved lists the properties depending on expressions which can not be evaluated statically,
+	// their values are left empty.
+	Unresolved []string `json:"unresolved,omitempty"`
+}
+
+// variantConfig is the version related configuration of defaultConfig, a product flavor or a build type.
+type variantConfig struct {
+	name string
+	body string
+}
+
+// property returns the literal value of the property, set is false if the property is not assigned
+// and ok is false if it is assigned an expression.
+func (c variantConfig) property(name string) (value string, set bool, ok bool) {
+	p, set := findGradleProperty(c.body, name)
+	if !set {
+		return "", false, true
+	}
+	value, ok = gradleLiteral(p.Value)
+	return value, true, ok
+}
+
+// ParseBuildTypes returns the build types of the build.gradle content, including the implicit debug and release build types.
+func ParseBuildTypes(content string) []GradleBlock {
+	declared := map[string]bool{}
+	var buildTypes []GradleBlock
+	for _, block := range findGradleBlocks(content, "android", "buildTypes") {
+		for _, b := range gradleBlocks(block.Body) {
+			b.Start += block.Start
+			b.End += block.Start
+			declared[b.Name] = true
+			buildTypes = append(buildTypes, b)
+		}
+	}
+
+	var implicit []GradleBlock
+	for _, name := range []string{"debug", "release"} {
+		if !declared[name] {
+			implicit = append(implicit, GradleBlock{Name: name})
+		}
+	}
+	return append(implicit, buildTypes...)
+}
+
+// ResolveVariantVersions statically computes the effective versionCode, versionName and applicationId of every variant.
+// The highest priority flavor (of the first dimension) setting versionCode, versionName or applicationId overrides defaultConfig,
+// versionNameSuffix and applicationIdSuffix are appended in defaultConfig, flavor dimension, build type order.
+// If no applicationId is set, the namespace is used.
+func ResolveVariantVersions(content string) ([]VariantVersion, error) {
+	defaultConfig := variantConfig{name: "defaultConfig"}
+	if block, ok := findGradleBlock(content, "android", "defaultConfig"); ok {
+		defaultConfig.body = block.Body
+	}
+	namespace := variantConfig{name: "android"}
+	if block, ok := findGradleBlock(content, "android"); ok {
+		namespace.body = block.Body
+	}
+
+	combinations, err := FlavorCombinations(ParseFlavorDimensions(content), ParseProductFlavors(content))
+	if err != nil {
+		return nil, err
+	}
+	if len(combinations) == 0 {
+		combinations = [][]ProductFlavor{{}}
+	}
+
+	var versions []VariantVersion
+	for _, combination := range combinations {
+		for _, buildType := range ParseBuildTypes(content) {
+			var names []string
+			var flavors []variantConfig
+			for _, f := range combination {
+				names = append(names, f.Name)
+				flavors = append(flavors, variantConfig{name: f.Name, body: f.Body})
+			}
+			names = append(names, buildType.Name)
+
+			// the flavors of the first dimension have the highest priority, defaultConfig the lowest
+			byPriority := append(append([]variantConfig{}, flavors...), defaultConfig)
+
+			v := VariantVersion{Variant: variantName(names...)}
+			unresolved := map[string]bool{}
+
+			for _, property := range []struct {
+				name  string
+				value *string
+			}{
+				{"versionCode", &v.VersionCode},
+				{"versionName", &v.VersionName},
+				{"applicationId", &v.ApplicationID},
+			} {
+				for _, c := range byPriority {
+					value, set, ok := c.property(property.name)
+					if !set {
+						continue
+					}
+					if !ok {
+						unresolved[property.name] = true
+					}
+					*property.value = value
+					break
+				}
+			}
+
+			if v.ApplicationID == "" && !unresolved["applicationId"] {
+				value, set, ok := namespace.property("namespace")
+				if !set || !ok {
+					unresolved["applicationId"] = true
+				}
+				v.ApplicationID = value
+			}
+
+			suffixConfigs := append(append([]variantConfig{defaultConfig}, flavors...), variantConfig{name: buildType.Name, body: buildType.Body})
+			for _, c := range suffixConfigs {
+				if suffix, set, ok := c.property("versionNameSuffix"); !ok {
+					unresolved["versionName"] = true
+				} else if set {
+					v.VersionName += suffix
+				}
+				if suffix, set, ok := c.property("applicationIdSuffix"); !ok {
+					unresolved["applicationId"] = true
+				} else if set && suffix != "" {
+					if !strings.HasPrefix(suffix, ".") {
+						suffix = "." + suffix
+					}
+					v.ApplicationID += suffix
+				}
+			}
+
+			for _, name := range []string{"versionCode", "versionName", "applicationId"} {
+				if !unresolved[name] {
+					continue
+				}
+				v.Unresolved = append(v.Unresolved, name)
+				switch name {
+				case "versionCode":
+					v.VersionCode = ""
+				case "versionName":
+					v.VersionName = ""
+				case "applicationId":
+					v.ApplicationID = ""
+				}
+			}
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
+
+// resolveVariantVersions statically computes the effective versions of every variant of the updated build.gradle.
+// It returns them as JSON.
+func resolveVariantVersions(res UpdateResult) (string, error) {
+	versions, err := ResolveVariantVersions(res.NewContent)
+	if err != nil {
+		return "", err
+	}
+
+	for _, v := range versions {
+		log.Printf("%s: versionCode: %s, versionName: %s, applicationId: %s", v.Variant, v.VersionCode, v.VersionName, v.ApplicationID)
+		if len(v.Unresolved) > 0 {
+			log.Warnf("%s: %s depend on expressions which can not be evaluated", v.Variant, strings.Join(v.Unresolved, ", "))
+		}
+	}
+
+	out, err := json.Marshal(versions)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveVariantVersions(t *testing.T) {
+	content := `android {
+    namespace "com.example.app"
+    flavorDimensions "tier"
+    defaultConfig {
+        applicationId "com.example"
+        versionCode 2042
+        versionName "1.2.0"
+    }
+    productFlavors {
+        free {
+            dimension "tier"
+            versionCode rootProject.ext.freeVersionCode
+        }
+        paid {
+            dimension "tier"
+            versionNameSuffix "-paid"
+            applicationIdSuffix "paid"
+        }
+    }
+    buildTypes {
+        release {
+            minifyEnabled true
+        }
+        qa {
+            versionNameSuffix "-qa"
+            applicationIdSuffix ".qa"
+        }
+    }
+}`
+
+	got, err := ResolveVariantVersions(content)
+	if err != nil {
+		t.Fatalf("ResolveVariantVersions() error = %s", err)
+	}
+	want := []VariantVersion{
+		{Variant: "freeDebug", VersionName: "1.2.0", ApplicationID: "com.example", Unresolved: []string{"versionCode"}},
+		{Variant: "freeRelease", VersionName: "1.2.0", ApplicationID: "com.example", Unresolved: []string{"versionCode"}},
+		{Variant: "freeQa", VersionName: "1.2.0-qa", ApplicationID: "com.example.qa", Unresolved: []string{"versionCode"}},
+		{Variant: "paidDebug", VersionCode: "2042", VersionName: "1.2.0-paid", ApplicationID: "com.example.paid"},
+		{Variant: "paidRelease", VersionCode: "2042", VersionName: "1.2.0-paid", ApplicationID: "com.example.paid"},
+		{Variant: "paidQa", VersionCode: "2042", VersionName: "1.2.0-paid-qa", ApplicationID: "com.example.paid.qa"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveVariantVersions() = %+v, want %+v", got, want)
+	}
+}
+
+func TestResolveVariantVersions_NoFlavors(t *testing.T) {
+	got, err := ResolveVariantVersions(`android {
+    namespace = "com.example.app"
+    defaultConfig {
+        versionCode = 3
+        versionName = "1.0.${build}"
+    }
+}`)
+	if err != nil {
+		t.Fatalf("ResolveVariantVersions() error = %s", err)
+	}
+	want := []VariantVersion{
+		{Variant: "debug", VersionCode: "3", ApplicationID: "com.example.app", Unresolved: []string{"versionName"}},
+		{Variant: "release", VersionCode: "3", ApplicationID: "com.example.app", Unresolved: []string{"versionName"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveVariantVersions() = %+v, want %+v", got, want)
+	}
+}
+
+func TestResolveVariantVersions_DimensionPriority(t *testing.T) {
+	got, err := ResolveVariantVersions(`android {
+    flavorDimensions "store", "tier"
+    defaultConfig {
+        applicationId "com.example"
+        versionCode 1
+        versionName "1.0.0"
+    }
+    productFlavors {
+        play {
+            dimension "store"
+            versionCode 100
+        }
+        free {
+            dimension "tier"
+            versionCode 200
+            versionName "2.0.0"
+        }
+    }
+}`)
+	if err != nil {
+		t.Fatalf("ResolveVariantVersions() error = %s", err)
+	}
+	want := []VariantVersion{
+		{Variant: "playFreeDebug", VersionCode: "100", VersionName: "2.0.0", ApplicationID: "com.example"},
+		{Variant: "playFreeRelease", VersionCode: "100", VersionName: "2.0.0", ApplicationID: "com.example"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveVariantVersions() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NamedVersionCode is a derived versionCode, like the versionCode of a split output, a variant or a module.
+type NamedVersionCode struct {
+	Name        string
+	VersionCode int
+}
+
+// ValidateVersionCodes checks that every versionCode is in the Google Play range and unique.
+func ValidateVersionCodes(codes []NamedVersionCode) []string {
+	var violations []string
+	byCode := map[int][]string{}
+	for _, c := range codes {
+		if c.VersionCode <= 0 || c.VersionCode > versionCodeMax {
+			violations = append(violations, fmt.Sprintf("%s: versionCode (%d) is out of range ]0..%d]", c.Name, c.VersionCode, versionCodeMax))
+		}
+		byCode[c.VersionCode] = append(byCode[c.VersionCode], c.Name)
+	}
+
+	var duplicates []int
+	for code, names := range byCode {
+		if len(names) > 1 {
+			duplicates = append(duplicates, code)
+		}
+	}
+	sort.Ints(duplicates)
+	for _, code := range duplicates {
+		violations = append(violations, fmt.Sprintf("%s have the same versionCode (%d)", strings.Join(byCode[code], ", "), code))
+	}
+	return violations
+}
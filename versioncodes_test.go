@@ -0,0 +1,23 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateVersionCodes(t *testing.T) {
+	codes := []NamedVersionCode{
+		{Name: "playFree", VersionCode: 1042},
+		{Name: "playPaid", VersionCode: 0},
+		{Name: "amazonFree", VersionCode: 1042},
+		{Name: "amazonPaid", VersionCode: 2100000001},
+	}
+	want := []string{
+		"playPaid: versionCode (0) is out of range ]0..2100000000]",
+		"amazonPaid: versionCode (2100000001) is out of range ]0..2100000000]",
+		"playFree, amazonFree have the same versionCode (1042)",
+	}
+	if got := ValidateVersionCodes(codes); !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateVersionCodes() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var versionComponentsRegexp = regexp.MustCompile(`^(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// versionNamePlaceholders are the {name|filter} placeholders of the versionName template.
+// The substituted values are validated, so that the versionName remains a valid string literal once quoted.
+var versionNamePlaceholders = PlaceholderTemplate{pattern: placeholderRegexp, check: func(value string) error {
+	if strings.ContainsAny(value, "\"'\\$\n\r") {
+		return fmt.Errorf("value (%s) contains quote, backslash, $ or line break characters, use the slug filter", value)
+	}
+	return nil
+}}
+
+// HasVersionNamePlaceholders reports whether the versionName contains {name} placeholders to expand.
+func HasVersionNamePlaceholders(versionName string) bool {
+	return versionNamePlaceholders.HasPlaceholders(versionName)
+}
+
+// ExpandVersionNameTemplate substitutes the {name|filter} placeholders of the versionName template with the values
+// returned by resolve, after applying the filters. ${name} string interpolations are left as is.
+func ExpandVersionNameTemplate(template string, resolve func(name string) (string, error)) (string, error) {
+	expanded, err := versionNamePlaceholders.Expand(template, resolve)
+	if err != nil {
+		return "", fmt.Errorf("versionName template (%s): %s", template, err)
+	}
+	if strings.Trim(expanded, `"`) == "" {
+		return "", fmt.Errorf("versionName template (%s) expanded to an empty versionName", template)
+	}
+	return expanded, nil
+}
+
+// versionNameComponents returns the major, minor and patch components of the versionName, missing components are 0.
+func versionNameComponents(versionName string) (map[string]string, error) {
+	match := versionComponentsRegexp.FindStringSubmatch(versionName)
+	if match == nil {
+		return nil, fmt.Errorf("versionName (%s) does not start with a major.minor.patch version", versionName)
+	}
+
+	components := map[string]string{}
+	for i, name := range []string{"major", "minor", "patch"} {
+		components[name] = match[i+1]
+		if components[name] == "" {
+			components[name] = "0"
+		}
+	}
+	return components, nil
+}
+
+// versionNameTemplateResolver returns the values of the versionName template placeholders:
+// {major}, {minor} and {patch} of the current versionName, {build}, {date}, {sha}, {branch} and {env.NAME}.
+func versionNameTemplateResolver(cfg config, current UpdateResult) func(name string) (string, error) {
+	repo := NewGitRepository(cfg.GitRepositoryPth)
+	return func(name string) (string, error) {
+		switch name {
+		case "major", "minor", "patch":
+			versionName := removeQuotationMarks(current.FinalVersionName)
+			if versionName == current.FinalVersionName {
+				return "", fmt.Errorf("current versionName (%s) is not a literal", current.FinalVersionName)
+			}
+			components, err := versionNameComponents(versionName)
+			if err != nil {
+				return "", err
+			}
+			return components[name], nil
+		case "build":
+			if cfg.BuildNumber == "" {
+				return "", errors.New("build_number is not set")
+			}
+			return cfg.BuildNumber, nil
+		case "date":
+			location, err := time.LoadLocation(cfg.VersionCodeTimeZone)
+			if err != nil {
+				return "", fmt.Errorf("invalid time zone (%s): %s", cfg.VersionCodeTimeZone, err)
+			}
+			return time.Now().In(location).Format("20060102"), nil
+		case "sha":
+			return repo.ShortCommitHash()
+		case "branch":
+			if cfg.Branch != "" {
+				return cfg.Branch, nil
+			}
+			return repo.CurrentBranch()
+		}
+
+		if strings.HasPrefix(name, "env.") {
+			value, ok := os.LookupEnv(strings.TrimPrefix(name, "env."))
+			if !ok {
+				return "", fmt.Errorf("environment variable (%s) is not set", strings.TrimPrefix(name, "env."))
+			}
+			return value, nil
+		}
+		return "", fmt.Errorf("%s, available placeholders: major, minor, patch, build, date, sha, branch, env.NAME", errUnknownPlaceholder)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExpandVersionNameTemplate(t *testing.T) {
+	values := map[string]string{
+		"major":      "1",
+		"minor":      "2",
+		"build":      "42",
+		"branch":     "feature/ABC-123 Login",
+		"env.SUFFIX": `rc"1`,
+	}
+	resolve := func(name string) (string, error) {
+		if value, ok := values[name]; ok {
+			return value, nil
+		}
+		return "", fmt.Errorf("unknown placeholder")
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{name: "Components and filters", template: "{major}.{minor}.{build}-{branch|slug}", want: "1.2.42-feature-abc-123-login"},
+		{name: "Chained filters", template: `"{major}.{minor}-{branch|slug|truncate:7|upper}"`, want: `"1.2-FEATURE"`},
+		{name: "String interpolation is kept", template: `"${versionMajor}.{build}"`, want: `"${versionMajor}.42"`},
+		{name: "Invalid characters", template: "{major}-{env.SUFFIX}", wantErr: true},
+		{name: "Sanitized by slug", template: "{major}-{env.SUFFIX|slug}", want: "1-rc-1"},
+		{name: "Unknown placeholder", template: "{major}.{micro}", wantErr: true},
+		{name: "Unknown filter", template: "{branch|camel}", wantErr: true},
+		{name: "Invalid truncate length", template: "{branch|truncate:0}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandVersionNameTemplate(tt.template, resolve)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandVersionNameTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandVersionNameTemplate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasVersionNamePlaceholders(t *testing.T) {
+	for versionName, want := range map[string]bool{
+		"1.2.0":                   false,
+		`"${versionMajor}.2.0"`:   false,
+		"{major}.{minor}.{build}": true,
+	} {
+		if got := HasVersionNamePlaceholders(versionName); got != want {
+			t.Errorf("HasVersionNamePlaceholders(%s) = %v, want %v", versionName, got, want)
+		}
+	}
+}
+
+func Test_versionNameComponents(t *testing.T) {
+	got, err := versionNameComponents("1.2-beta")
+	if err != nil {
+		t.Fatalf("versionNameComponents() error = %s", err)
+	}
+	if got["major"] != "1" || got["minor"] != "2" || got["patch"] != "0" {
+		t.Errorf("versionNameComponents() = %v", got)
+	}
+
+	if _, err := versionNameComponents("beta"); err == nil {
+		t.Errorf("versionNameComponents() expected error")
+	}
+}